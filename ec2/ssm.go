@@ -2,6 +2,8 @@ package ec2
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	awscfn "github.com/aws/aws-k8s-tester/pkg/aws/cloudformation"
+	"github.com/aws/aws-k8s-tester/pkg/aws/naming"
 	"github.com/aws/aws-k8s-tester/version"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
@@ -17,6 +20,48 @@ import (
 	"go.uber.org/zap"
 )
 
+// ssmCommandTerminalStatuses are the GetCommandInvocation statuses that mean
+// a command has stopped running on an instance, one way or another.
+var ssmCommandTerminalStatuses = map[string]bool{
+	ssm.CommandInvocationStatusSuccess:   true,
+	ssm.CommandInvocationStatusFailed:    true,
+	ssm.CommandInvocationStatusCancelled: true,
+	ssm.CommandInvocationStatusTimedOut:  true,
+}
+
+// cfnStackFailureStatuses are the terminal CloudFormation resource statuses
+// that mean the SSM document stack did not reach the desired state, as
+// opposed to CREATE_IN_PROGRESS/ROLLBACK_IN_PROGRESS/DELETE_IN_PROGRESS which
+// are still pending.
+var cfnStackFailureStatuses = map[string]bool{
+	cloudformation.ResourceStatusCreateFailed:     true,
+	cloudformation.ResourceStatusRollbackComplete: true,
+	cloudformation.ResourceStatusRollbackFailed:   true,
+	cloudformation.ResourceStatusDeleteFailed:     true,
+}
+
+// describeStackFailureReason calls DescribeStackEvents for stackID and
+// returns "<LogicalResourceId>: <ResourceStatusReason>" of the most recent
+// event whose status is in cfnStackFailureStatuses, so a failed SSM document
+// stack surfaces *why*
+// it failed rather than an opaque polling error. Returns "" if the events
+// can't be fetched or none match.
+func (ts *Tester) describeStackFailureReason(stackID string) string {
+	out, err := ts.cfnAPI.DescribeStackEvents(&cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackID),
+	})
+	if err != nil {
+		ts.lg.Warn("failed to describe stack events", zap.String("cfn-stack-id", stackID), zap.Error(err))
+		return ""
+	}
+	for _, ev := range out.StackEvents {
+		if cfnStackFailureStatuses[aws.StringValue(ev.ResourceStatus)] {
+			return fmt.Sprintf("%s: %s", aws.StringValue(ev.LogicalResourceId), aws.StringValue(ev.ResourceStatusReason))
+		}
+	}
+	return ""
+}
+
 func (ts *Tester) createSSM() error {
 	if err := ts.createSSMDocument(); err != nil {
 		return err
@@ -97,6 +142,139 @@ Outputs:
 
 `
 
+// ssmDocumentRunCommand is the shell script embedded in both the CFN
+// template above and the direct-SSM document content below, so the two
+// backends run identical bootstrap logic.
+const ssmDocumentRunCommand = `set -xue
+log() {
+  echo -e "[$(date -u +'%Y-%m-%dT%H:%M:%SZ')] $1"
+}
+AWS_DEFAULT_REGION={{region}}
+log "running SSM with AWS_DEFAULT_REGION: ${AWS_DEFAULT_REGION}"
+
+log "running more SSM command"
+{{ moreCommands }}
+`
+
+type ssmDocumentContent struct {
+	SchemaVersion string                      `json:"schemaVersion"`
+	Description   string                      `json:"description"`
+	Parameters    map[string]ssmDocumentParam `json:"parameters"`
+	MainSteps     []ssmDocumentMainStep       `json:"mainSteps"`
+}
+
+type ssmDocumentParam struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+}
+
+type ssmDocumentMainStep struct {
+	Action string                `json:"action"`
+	Name   string                `json:"name"`
+	Inputs ssmDocumentStepInputs `json:"inputs"`
+}
+
+type ssmDocumentStepInputs struct {
+	TimeoutSeconds string   `json:"timeoutSeconds"`
+	RunCommand     []string `json:"runCommand"`
+}
+
+// buildSSMDocumentContent renders the same schemaVersion 2.2 content
+// embedded in TemplateSSMDocument's AWS::SSM::Document resource, so the
+// direct-SSM and CFN backends stay in lockstep.
+func buildSSMDocumentContent(documentName, commands string) (string, error) {
+	b, err := json.Marshal(ssmDocumentContent{
+		SchemaVersion: "2.2",
+		Description:   "SSM document to bootstrap EC2.",
+		Parameters: map[string]ssmDocumentParam{
+			"region":                  {Type: "String", Description: "AWS Region"},
+			"executionTimeoutSeconds": {Type: "String", Description: "timeout for script, in seconds"},
+			"moreCommands":            {Type: "String", Description: "more commands", Default: commands},
+		},
+		MainSteps: []ssmDocumentMainStep{
+			{
+				Action: "aws:runShellScript",
+				Name:   documentName,
+				Inputs: ssmDocumentStepInputs{
+					TimeoutSeconds: "{{ executionTimeoutSeconds }}",
+					RunCommand:     []string{ssmDocumentRunCommand},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// createSSMDocumentDirect calls ssm.CreateDocument directly instead of
+// wrapping a single AWS::SSM::Document in a CloudFormation stack, cutting
+// several minutes of CFN stack-lifecycle latency per ASG.
+func (ts *Tester) createSSMDocumentDirect(asgName string, cur ec2config.ASG) error {
+	if cur.SSMDocumentName != "" {
+		ts.lg.Info("SSM document already created; skipping direct create",
+			zap.String("asg-name", asgName),
+			zap.String("ssm-document-name", cur.SSMDocumentName),
+		)
+		return nil
+	}
+
+	ts.lg.Info("creating SSM document directly", zap.String("asg-name", asgName))
+	content, err := buildSSMDocumentContent(cur.Name, cur.SSMDocumentCommands)
+	if err != nil {
+		return err
+	}
+	out, err := ts.ssmAPI.CreateDocument(&ssm.CreateDocumentInput{
+		Name:           aws.String(cur.Name),
+		DocumentType:   aws.String(ssm.DocumentTypeCommand),
+		DocumentFormat: aws.String(ssm.DocumentFormatJson),
+		Content:        aws.String(content),
+	})
+	if err != nil {
+		return err
+	}
+	cur.SSMDocumentName = aws.StringValue(out.DocumentDescription.Name)
+	cur.SSMDocumentCommandsApplied = cur.SSMDocumentCommands
+	if err := ts.recordSSMDocumentVersions(&cur); err != nil {
+		return err
+	}
+	ts.cfg.ASGs[asgName] = cur
+	ts.cfg.Sync()
+
+	ts.lg.Info("created SSM document directly",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+	)
+	return nil
+}
+
+// deleteSSMDocumentDirect calls ssm.DeleteDocument directly, the
+// direct-SSM counterpart of deleteSSMDocument's DeleteStack call.
+func (ts *Tester) deleteSSMDocumentDirect(asgName string, cur ec2config.ASG) error {
+	if err := ts.revokeSSMDocumentShare(asgName, cur); err != nil {
+		return err
+	}
+	ts.lg.Info("deleting SSM document directly",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+	)
+	_, err := ts.ssmAPI.DeleteDocument(&ssm.DeleteDocumentInput{
+		Name: aws.String(cur.SSMDocumentName),
+	})
+	if err != nil {
+		ts.cfg.RecordStatus(fmt.Sprintf("failed to delete SSM Document (%v)", err))
+		return err
+	}
+	ts.cfg.RecordStatus(fmt.Sprintf("%q/%s", cur.SSMDocumentName, ec2config.StatusDELETEDORNOTEXIST))
+	ts.lg.Info("deleted SSM document directly",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+	)
+	return nil
+}
+
 func (ts *Tester) createSSMDocument() error {
 	createStart := time.Now()
 
@@ -108,6 +286,25 @@ func (ts *Tester) createSSMDocument() error {
 			)
 			continue
 		}
+		if !cur.SSMDocumentUseCFN {
+			if err := ts.createSSMDocumentDirect(asgName, cur); err != nil {
+				return err
+			}
+			if err := ts.reconcileSSMDocumentShare(asgName, ts.cfg.ASGs[asgName]); err != nil {
+				return err
+			}
+			continue
+		}
+		if cur.SSMDocumentCFNStackID != "" {
+			if err := ts.updateSSMDocumentIfChanged(asgName, cur); err != nil {
+				return err
+			}
+			if err := ts.reconcileSSMDocumentShare(asgName, ts.cfg.ASGs[asgName]); err != nil {
+				return err
+			}
+			continue
+		}
+
 		ts.lg.Info("creating SSM document",
 			zap.String("asg-name", asgName),
 			zap.String("ssm-document-name", cur.SSMDocumentName),
@@ -170,6 +367,9 @@ func (ts *Tester) createSSMDocument() error {
 		}
 		cancel()
 		if st.Error != nil {
+			if reason := ts.describeStackFailureReason(cur.SSMDocumentCFNStackID); reason != "" {
+				return fmt.Errorf("%v (%s)", st.Error, reason)
+			}
 			return st.Error
 		}
 		// update status after creating a new ASG
@@ -183,6 +383,11 @@ func (ts *Tester) createSSMDocument() error {
 			}
 		}
 
+		cur.SSMDocumentCommandsApplied = cur.SSMDocumentCommands
+		if err := ts.recordSSMDocumentVersions(&cur); err != nil {
+			return err
+		}
+
 		ts.lg.Info("created SSM Document",
 			zap.String("asg-name", cur.Name),
 			zap.String("ssm-document-name", cur.SSMDocumentName),
@@ -191,11 +396,200 @@ func (ts *Tester) createSSMDocument() error {
 		)
 		ts.cfg.ASGs[asgName] = cur
 		ts.cfg.Sync()
+
+		if err := ts.reconcileSSMDocumentShare(asgName, ts.cfg.ASGs[asgName]); err != nil {
+			return err
+		}
 	}
 
 	return ts.cfg.Sync()
 }
 
+// reconcileSSMDocumentShare compares cur.SSMDocumentShareAccountIDs against
+// what's already shared (SSMDocumentShareAccountIDsApplied) and calls
+// ModifyDocumentPermission with exactly the accounts that need adding or
+// removing, mirroring Terraform's `permissions { type = "Share" }` lifecycle
+// so re-running with an edited account list converges instead of erroring
+// on accounts that are already shared.
+func (ts *Tester) reconcileSSMDocumentShare(asgName string, cur ec2config.ASG) error {
+	desired := make(map[string]bool, len(cur.SSMDocumentShareAccountIDs))
+	for _, id := range cur.SSMDocumentShareAccountIDs {
+		desired[id] = true
+	}
+	applied := make(map[string]bool, len(cur.SSMDocumentShareAccountIDsApplied))
+	for _, id := range cur.SSMDocumentShareAccountIDsApplied {
+		applied[id] = true
+	}
+
+	var toAdd, toRemove []string
+	for id := range desired {
+		if !applied[id] {
+			toAdd = append(toAdd, id)
+		}
+	}
+	for id := range applied {
+		if !desired[id] {
+			toRemove = append(toRemove, id)
+		}
+	}
+	if len(toAdd) == 0 && len(toRemove) == 0 {
+		return nil
+	}
+
+	ts.lg.Info("reconciling SSM document share accounts",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+		zap.Strings("account-ids-to-add", toAdd),
+		zap.Strings("account-ids-to-remove", toRemove),
+	)
+	input := &ssm.ModifyDocumentPermissionInput{
+		Name:           aws.String(cur.SSMDocumentName),
+		PermissionType: aws.String(ssm.DocumentPermissionTypeShare),
+	}
+	if len(toAdd) > 0 {
+		input.AccountIdsToAdd = aws.StringSlice(toAdd)
+	}
+	if len(toRemove) > 0 {
+		input.AccountIdsToRemove = aws.StringSlice(toRemove)
+	}
+	if _, err := ts.ssmAPI.ModifyDocumentPermission(input); err != nil {
+		return fmt.Errorf("failed to reconcile SSM document share accounts (%v)", err)
+	}
+
+	cur.SSMDocumentShareAccountIDsApplied = cur.SSMDocumentShareAccountIDs
+	ts.cfg.ASGs[asgName] = cur
+	ts.cfg.Sync()
+	return nil
+}
+
+// revokeSSMDocumentShare removes every previously-shared account, so a
+// deleted SSM document doesn't leave dangling cross-account permissions
+// behind.
+func (ts *Tester) revokeSSMDocumentShare(asgName string, cur ec2config.ASG) error {
+	if len(cur.SSMDocumentShareAccountIDsApplied) == 0 {
+		return nil
+	}
+	ts.lg.Info("revoking SSM document share accounts",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+		zap.Strings("account-ids-to-remove", cur.SSMDocumentShareAccountIDsApplied),
+	)
+	_, err := ts.ssmAPI.ModifyDocumentPermission(&ssm.ModifyDocumentPermissionInput{
+		Name:               aws.String(cur.SSMDocumentName),
+		PermissionType:     aws.String(ssm.DocumentPermissionTypeShare),
+		AccountIdsToRemove: aws.StringSlice(cur.SSMDocumentShareAccountIDsApplied),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke SSM document share accounts (%v)", err)
+	}
+	cur.SSMDocumentShareAccountIDsApplied = nil
+	ts.cfg.ASGs[asgName] = cur
+	ts.cfg.Sync()
+	return nil
+}
+
+// updateSSMDocumentIfChanged reconciles a previously-created SSM document's
+// CFN stack against the live SSMDocumentCommands, mirroring the Terraform
+// aws_ssm_document update lifecycle: if the commands changed since the last
+// apply, it calls UpdateStack, polls for UPDATE_COMPLETE, and records the
+// document's new schema/latest/default version so a resumed test run can
+// roll the document forward without tearing down the ASG.
+func (ts *Tester) updateSSMDocumentIfChanged(asgName string, cur ec2config.ASG) error {
+	if cur.SSMDocumentCommands == cur.SSMDocumentCommandsApplied {
+		ts.lg.Info("SSM document commands unchanged; skipping update",
+			zap.String("asg-name", asgName),
+			zap.String("ssm-document-name", cur.SSMDocumentName),
+		)
+		return nil
+	}
+
+	ts.lg.Info("updating SSM document",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+		zap.String("cfn-stack-id", cur.SSMDocumentCFNStackID),
+	)
+	updateInput := &cloudformation.UpdateStackInput{
+		StackName:    aws.String(cur.SSMDocumentCFNStackID),
+		Capabilities: aws.StringSlice([]string{"CAPABILITY_IAM"}),
+		TemplateBody: aws.String(TemplateSSMDocument),
+		Parameters: []*cloudformation.Parameter{
+			{
+				ParameterKey:   aws.String("Name"),
+				ParameterValue: aws.String(ts.cfg.Name),
+			},
+			{
+				ParameterKey:   aws.String("DocumentName"),
+				ParameterValue: aws.String(cur.SSMDocumentName),
+			},
+			{
+				ParameterKey:   aws.String("Commands"),
+				ParameterValue: aws.String(cur.SSMDocumentCommands),
+			},
+		},
+	}
+	if _, err := ts.cfnAPI.UpdateStack(updateInput); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	ch := awscfn.Poll(
+		ctx,
+		ts.stopCreationCh,
+		ts.interruptSig,
+		ts.lg,
+		ts.cfnAPI,
+		cur.SSMDocumentCFNStackID,
+		cloudformation.ResourceStatusUpdateComplete,
+		time.Minute,
+		30*time.Second,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			ts.cfg.RecordStatus(fmt.Sprintf("failed to update SSM document (%v)", st.Error))
+			ts.lg.Warn("polling errror", zap.Error(st.Error))
+		}
+	}
+	cancel()
+	if st.Error != nil {
+		if reason := ts.describeStackFailureReason(cur.SSMDocumentCFNStackID); reason != "" {
+			return fmt.Errorf("%v (%s)", st.Error, reason)
+		}
+		return st.Error
+	}
+
+	cur.SSMDocumentCommandsApplied = cur.SSMDocumentCommands
+	if err := ts.recordSSMDocumentVersions(&cur); err != nil {
+		return err
+	}
+	ts.cfg.ASGs[asgName] = cur
+	ts.cfg.Sync()
+
+	ts.lg.Info("updated SSM document",
+		zap.String("asg-name", asgName),
+		zap.String("ssm-document-name", cur.SSMDocumentName),
+		zap.String("latest-version", cur.SSMDocumentLatestVersion),
+		zap.String("default-version", cur.SSMDocumentDefaultVersion),
+	)
+	return nil
+}
+
+// recordSSMDocumentVersions calls DescribeDocument to capture the SSM
+// document's schema/latest/default version, so a resumed test run can tell
+// whether a previously-created document needs to roll forward.
+func (ts *Tester) recordSSMDocumentVersions(cur *ec2config.ASG) error {
+	out, err := ts.ssmAPI.DescribeDocument(&ssm.DescribeDocumentInput{
+		Name: aws.String(cur.SSMDocumentName),
+	})
+	if err != nil {
+		return err
+	}
+	cur.SSMDocumentSchemaVersion = aws.StringValue(out.Document.SchemaVersion)
+	cur.SSMDocumentLatestVersion = aws.StringValue(out.Document.LatestVersion)
+	cur.SSMDocumentDefaultVersion = aws.StringValue(out.Document.DefaultVersion)
+	return nil
+}
+
 func (ts *Tester) deleteSSMDocument() error {
 	for asgName, cur := range ts.cfg.ASGs {
 		if !cur.SSMDocumentCreate {
@@ -205,6 +599,15 @@ func (ts *Tester) deleteSSMDocument() error {
 			)
 			continue
 		}
+		if !cur.SSMDocumentUseCFN {
+			if err := ts.deleteSSMDocumentDirect(asgName, cur); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ts.revokeSSMDocumentShare(asgName, cur); err != nil {
+			return err
+		}
 		ts.lg.Info("deleting SSM document",
 			zap.String("asg-name", cur.Name),
 			zap.String("ssm-document-name", cur.SSMDocumentName),
@@ -240,6 +643,9 @@ func (ts *Tester) deleteSSMDocument() error {
 		}
 		cancel()
 		if st.Error != nil {
+			if reason := ts.describeStackFailureReason(cur.SSMDocumentCFNStackID); reason != "" {
+				return fmt.Errorf("%v (%s)", st.Error, reason)
+			}
 			return st.Error
 		}
 		ts.cfg.RecordStatus(fmt.Sprintf("%q/%s", cur.SSMDocumentName, ec2config.StatusDELETEDORNOTEXIST))
@@ -276,7 +682,7 @@ func (ts *Tester) sendSSMDocumentCommand() error {
 		)
 		ssmInput := &ssm.SendCommandInput{
 			DocumentName:   aws.String(cur.SSMDocumentName),
-			Comment:        aws.String(cur.SSMDocumentName + "-" + randString(10)),
+			Comment:        aws.String(naming.Gen(cur.SSMDocumentName, 10)),
 			InstanceIds:    aws.StringSlice(ids),
 			MaxConcurrency: aws.String(fmt.Sprintf("%d", len(ids))),
 			Parameters: map[string][]*string{
@@ -305,7 +711,91 @@ func (ts *Tester) sendSSMDocumentCommand() error {
 		)
 		ts.cfg.ASGs[asgName] = cur
 		ts.cfg.Sync()
+
+		if err := ts.waitSSMDocumentCommand(asgName, cur, ids); err != nil {
+			return err
+		}
 	}
 
 	return ts.cfg.Sync()
 }
+
+// waitSSMDocumentCommand polls GetCommandInvocation for each instance in ids
+// until every one reaches a terminal state (Success, Failed, Cancelled,
+// TimedOut) or SSMDocumentExecutionTimeoutSeconds elapses, persisting each
+// instance's exit code, stdout/stderr, and S3 output URLs back into
+// ec2config.ASG.Instances, and returns an error if any instance failed.
+func (ts *Tester) waitSSMDocumentCommand(asgName string, cur ec2config.ASG, ids []string) error {
+	timeout := time.Duration(cur.SSMDocumentExecutionTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Minute)
+	defer cancel()
+
+	pending := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		pending[id] = true
+	}
+
+	var failed []string
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for SSM command %q to complete on %v", cur.SSMDocumentCommandID, pendingInstanceIDs(pending))
+		case <-ts.stopCreationCh:
+			return errors.New("SSM command wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		for id := range pending {
+			out, err := ts.ssmAPI.GetCommandInvocation(&ssm.GetCommandInvocationInput{
+				CommandId:  aws.String(cur.SSMDocumentCommandID),
+				InstanceId: aws.String(id),
+			})
+			if err != nil {
+				ts.lg.Warn("failed to get SSM command invocation; retrying", zap.String("instance-id", id), zap.Error(err))
+				continue
+			}
+			status := aws.StringValue(out.Status)
+			if !ssmCommandTerminalStatuses[status] {
+				continue
+			}
+
+			instance := cur.Instances[id]
+			instance.SSMCommandStatus = status
+			instance.SSMCommandResponseCode = aws.Int64Value(out.ResponseCode)
+			instance.SSMCommandStdout = aws.StringValue(out.StandardOutputContent)
+			instance.SSMCommandStderr = aws.StringValue(out.StandardErrorContent)
+			instance.SSMCommandStdoutS3URL = aws.StringValue(out.StandardOutputUrl)
+			instance.SSMCommandStderrS3URL = aws.StringValue(out.StandardErrorUrl)
+			cur.Instances[id] = instance
+
+			ts.lg.Info("SSM command reached terminal state",
+				zap.String("instance-id", id),
+				zap.String("status", status),
+				zap.Int64("response-code", instance.SSMCommandResponseCode),
+			)
+			if status != ssm.CommandInvocationStatusSuccess {
+				failed = append(failed, id)
+			}
+			delete(pending, id)
+		}
+	}
+
+	ts.cfg.ASGs[asgName] = cur
+	ts.cfg.Sync()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("SSM command %q failed on instances %v", cur.SSMDocumentCommandID, failed)
+	}
+	return nil
+}
+
+func pendingInstanceIDs(pending map[string]bool) []string {
+	ids := make([]string, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -3,8 +3,9 @@ package alb
 
 import (
 	"bytes"
-	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/aws/elb"
+	"github.com/aws/aws-k8s-tester/pkg/k8sclient/retry"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
@@ -27,7 +29,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	clientset "k8s.io/client-go/kubernetes"
-	"k8s.io/utils/exec"
 )
 
 // Config defines ALB configuration.
@@ -51,6 +52,10 @@ type Tester interface {
 	Create() error
 	// Delete deletes all Job objects.
 	Delete() error
+	// IsExists queries the API server for every AddOnALB2048 subresource and
+	// reports whether each one already exists, so Create can resume a
+	// partial run and Delete can clean up regardless of the Created flag.
+	IsExists() map[string]bool
 }
 
 // New creates a new Job tester.
@@ -59,8 +64,7 @@ func New(cfg Config) (Tester, error) {
 }
 
 type tester struct {
-	cfg              Config
-	policyCFNStackID string // TODO: persist
+	cfg Config
 }
 
 const (
@@ -85,19 +89,152 @@ const (
 	alb2048DeploymentName = "alb-2048-deployment"
 	alb2048ServiceName    = "alb-2048-service"
 	alb2048IngressName    = "alb-2048-ingress"
+
+	// frontendUIDTagKey tags the ALB with a short hash derived from the
+	// kube-system Namespace UID, so Delete can find the right ALB even when
+	// two clusters share a name (ingress-gce's frontend naming scheme keys
+	// on the same cluster UID for the same reason).
+	frontendUIDTagKey = "aws-k8s-tester/frontend-uid"
+
+	// albDefaultSSLPolicy is used when AddOnALB2048.SSLPolicy is empty.
+	albDefaultSSLPolicy = "ELBSecurityPolicy-2016-08"
 )
 
-// ALBImageName is the image name of ALB Ingress Controller.
-// ref. https://github.com/kubernetes-sigs/aws-alb-ingress-controller/releases
-const ALBImageName = "docker.io/amazon/aws-alb-ingress-controller:v1.1.5"
+// albIngressAnnotations returns the "alb.ingress.kubernetes.io/*"
+// annotations for the 2048 Ingress, adding an HTTPS listener (and the
+// certificate/SSL-policy annotations the ALB Ingress Controller needs to
+// terminate TLS) only when AddOnALB2048.CertificateARN is set.
+func (ts *tester) albIngressAnnotations() map[string]string {
+	annotations := map[string]string{
+		"kubernetes.io/ingress.class":      "alb",
+		"alb.ingress.kubernetes.io/scheme": "internet-facing",
+		"alb.ingress.kubernetes.io/tags":   fmt.Sprintf("%s=%s", frontendUIDTagKey, ts.cfg.EKSConfig.AddOnALB2048.FrontendUID),
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.CertificateARN == "" {
+		return annotations
+	}
 
-// https://docs.aws.amazon.com/eks/latest/userguide/alb-ingress.html
-func (ts *tester) Create() error {
-	if ts.cfg.EKSConfig.AddOnALB2048.Created {
-		ts.cfg.Logger.Info("skipping create AddOnALB2048")
+	sslPolicy := ts.cfg.EKSConfig.AddOnALB2048.SSLPolicy
+	if sslPolicy == "" {
+		sslPolicy = albDefaultSSLPolicy
+	}
+	annotations["alb.ingress.kubernetes.io/listen-ports"] = `[{"HTTP":80},{"HTTPS":443}]`
+	annotations["alb.ingress.kubernetes.io/certificate-arn"] = ts.cfg.EKSConfig.AddOnALB2048.CertificateARN
+	annotations["alb.ingress.kubernetes.io/ssl-policy"] = sslPolicy
+	return annotations
+}
+
+// containerPort returns AddOnALB2048.ContainerPort, defaulting to 80 when
+// unset, so users can swap in a 2048 image (or their own app image) that
+// listens on a different port without patching the tester.
+func (ts *tester) containerPort() int32 {
+	if ts.cfg.EKSConfig.AddOnALB2048.ContainerPort > 0 {
+		return ts.cfg.EKSConfig.AddOnALB2048.ContainerPort
+	}
+	return 80
+}
+
+// ingress2048Paths returns the 2048 Ingress's HTTPIngressPath rules: a
+// single "/*" rule routing to the 2048 Service by default, or, in
+// AddOnALB2048.MultiPathRouting mode, "/game/*" plus the multiPathBackends()
+// paths, so the same Ingress can exercise ALB path-priority ordering.
+func (ts *tester) ingress2048Paths() []v1beta1.HTTPIngressPath {
+	if !ts.cfg.EKSConfig.AddOnALB2048.MultiPathRouting {
+		return []v1beta1.HTTPIngressPath{
+			{
+				Path: "/*",
+				Backend: v1beta1.IngressBackend{
+					ServiceName: alb2048ServiceName,
+					ServicePort: intstr.FromInt(int(ts.containerPort())),
+				},
+			},
+		}
+	}
+	return ts.multiPathIngressRules()
+}
+
+// keys into the map returned by IsExists.
+const (
+	resourceNamespace          = "namespace"
+	resourceServiceAccount     = "service-account"
+	resourceClusterRole        = "cluster-role"
+	resourceClusterRoleBinding = "cluster-role-binding"
+	resourceALBDeployment      = "alb-deployment"
+	resource2048Deployment     = "2048-deployment"
+	resource2048Service        = "2048-service"
+	resource2048Ingress        = "2048-ingress"
+)
+
+// IsExists queries the API server for every AddOnALB2048 subresource —
+// mirroring the Voyager ingress controller's Controller interface — so
+// Create can skip what already exists and Delete can still find resources to
+// clean up even when the Created flag was never persisted (e.g. the process
+// crashed mid-run).
+func (ts *tester) IsExists() map[string]bool {
+	cs := ts.cfg.K8SClient.KubernetesClientSet()
+	ns := ts.cfg.EKSConfig.AddOnALB2048.Namespace
+
+	exists := make(map[string]bool)
+
+	_, err := cs.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+	exists[resourceNamespace] = err == nil
+
+	_, err = cs.CoreV1().ServiceAccounts(albIngressControllerServiceAccountNamespace).Get(albIngressControllerServiceAccountName, metav1.GetOptions{})
+	exists[resourceServiceAccount] = err == nil
+
+	_, err = cs.RbacV1().ClusterRoles().Get(albIngressControllerRBACRoleName, metav1.GetOptions{})
+	exists[resourceClusterRole] = err == nil
+
+	_, err = cs.RbacV1().ClusterRoleBindings().Get(albIngressControllerRBACClusterRoleBindingName, metav1.GetOptions{})
+	exists[resourceClusterRoleBinding] = err == nil
+
+	_, err = cs.AppsV1().Deployments(albIngressControllerDeploymentNamespace).Get(albIngressControllerDeploymentName, metav1.GetOptions{})
+	exists[resourceALBDeployment] = err == nil
+
+	_, err = cs.AppsV1().Deployments(ns).Get(alb2048DeploymentName, metav1.GetOptions{})
+	exists[resource2048Deployment] = err == nil
+
+	_, err = cs.CoreV1().Services(ns).Get(alb2048ServiceName, metav1.GetOptions{})
+	exists[resource2048Service] = err == nil
+
+	_, err = cs.ExtensionsV1beta1().Ingresses(ns).Get(alb2048IngressName, metav1.GetOptions{})
+	exists[resource2048Ingress] = err == nil
+
+	return exists
+}
+
+// ensureFrontendUID reads the kube-system Namespace UID and hashes it with
+// the cluster name into a short, stable suffix, so the value survives a
+// tester restart yet still disambiguates ALBs between two clusters that
+// happen to share a name (common in CI).
+func (ts *tester) ensureFrontendUID() error {
+	if ts.cfg.EKSConfig.AddOnALB2048.FrontendUID != "" {
 		return nil
 	}
 
+	kubeSystem, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Namespaces().Get("kube-system", metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get kube-system Namespace UID for ALB frontend UID (%v)", err)
+	}
+
+	sum := sha256.Sum256([]byte(ts.cfg.EKSConfig.Name + "/" + string(kubeSystem.UID)))
+	ts.cfg.EKSConfig.AddOnALB2048.FrontendUID = hex.EncodeToString(sum[:4])
+	ts.cfg.Logger.Info("derived ALB frontend UID",
+		zap.String("kube-system-uid", string(kubeSystem.UID)),
+		zap.String("frontend-uid", ts.cfg.EKSConfig.AddOnALB2048.FrontendUID),
+	)
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// https://docs.aws.amazon.com/eks/latest/userguide/alb-ingress.html
+func (ts *tester) Create() (err error) {
+	exists := ts.IsExists()
+	ts.cfg.Logger.Info("checked existing AddOnALB2048 resources", zap.Any("exists", exists))
+
+	if err = ts.ensureFrontendUID(); err != nil {
+		return err
+	}
+
 	ts.cfg.EKSConfig.AddOnALB2048.Created = true
 	ts.cfg.EKSConfig.Sync()
 	createStart := time.Now()
@@ -105,50 +242,79 @@ func (ts *tester) Create() error {
 		ts.cfg.EKSConfig.AddOnALB2048.CreateTook = time.Since(createStart)
 		ts.cfg.EKSConfig.AddOnALB2048.CreateTookString = ts.cfg.EKSConfig.AddOnALB2048.CreateTook.String()
 		ts.cfg.EKSConfig.Sync()
+
+		if err != nil && ts.cfg.EKSConfig.OnFailureDeletePartial {
+			ts.cfg.Logger.Warn("reverting partially created AddOnALB2048 resources", zap.Error(err))
+			if derr := ts.Delete(); derr != nil {
+				ts.cfg.Logger.Warn("failed to revert partially created AddOnALB2048 resources", zap.Error(derr))
+			}
+		}
 	}()
 
-	if err := ts.createNamespace(); err != nil {
-		return err
+	if !exists[resourceNamespace] {
+		if err = ts.createNamespace(); err != nil {
+			return err
+		}
 	}
 
-	if err := ts.createALBServiceAccount(); err != nil {
-		return err
+	if !exists[resourceServiceAccount] {
+		if err = ts.createALBServiceAccount(); err != nil {
+			return err
+		}
 	}
-	if err := ts.createALBRBACClusterRole(); err != nil {
-		return err
+	if !exists[resourceClusterRole] {
+		if err = ts.createALBRBACClusterRole(); err != nil {
+			return err
+		}
 	}
-	if err := ts.createALBRBACClusterRoleBinding(); err != nil {
-		return err
+	if !exists[resourceClusterRoleBinding] {
+		if err = ts.createALBRBACClusterRoleBinding(); err != nil {
+			return err
+		}
 	}
-	if err := ts.createALBDeployment(); err != nil {
+	if err = ts.installALBControllerCRDs(); err != nil {
 		return err
 	}
-	if err := ts.waitDeploymentALB(); err != nil {
+	if !exists[resourceALBDeployment] {
+		if err = ts.createALBDeployment(); err != nil {
+			return err
+		}
+	}
+	if err = ts.waitDeploymentALB(); err != nil {
 		return err
 	}
 
-	if err := ts.create2048Deployment(); err != nil {
-		return err
+	if !exists[resource2048Deployment] {
+		if err = ts.create2048Deployment(); err != nil {
+			return err
+		}
 	}
-	if err := ts.waitDeployment2048(); err != nil {
+	if err = ts.waitDeployment2048(); err != nil {
 		return err
 	}
-	if err := ts.create2048Service(); err != nil {
-		return err
+	if !exists[resource2048Service] {
+		if err = ts.create2048Service(); err != nil {
+			return err
+		}
 	}
-	if err := ts.create2048Ingress(); err != nil {
-		return err
+	if !exists[resource2048Ingress] {
+		if err = ts.create2048Ingress(); err != nil {
+			return err
+		}
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.IngressMatrix {
+		if err = ts.create2048IngressMatrix(); err != nil {
+			return err
+		}
 	}
 
 	return ts.cfg.EKSConfig.Sync()
 }
 
+// Delete tears down every AddOnALB2048 subresource regardless of the Created
+// flag, since a crashed or resumed run may have created resources that were
+// never recorded (or may have recorded Created without finishing).
 func (ts *tester) Delete() error {
-	if !ts.cfg.EKSConfig.AddOnALB2048.Created {
-		ts.cfg.Logger.Info("skipping delete AddOnALB2048")
-		return nil
-	}
-
 	deleteStart := time.Now()
 	defer func() {
 		ts.cfg.EKSConfig.AddOnALB2048.DeleteTook = time.Since(deleteStart)
@@ -157,6 +323,11 @@ func (ts *tester) Delete() error {
 	}()
 
 	var errs []string
+	if ts.cfg.EKSConfig.AddOnALB2048.IngressMatrix {
+		if err := ts.delete2048IngressMatrix(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
 	if err := ts.delete2048Ingress(); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to delete ALB 2048 Ingress (%v)", err))
 	}
@@ -180,6 +351,10 @@ func (ts *tester) Delete() error {
 	ts.cfg.Logger.Info("wait for a minute after deleting ALB Deployment")
 	time.Sleep(time.Minute)
 
+	if err := ts.deleteALBControllerCRDs(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete AWS Load Balancer Controller v2 CRDs (%v)", err))
+	}
+
 	if err := ts.deleteALBRBACClusterRoleBinding(); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to delete ALB Ingress Controller RBAC (%v)", err))
 	}
@@ -215,15 +390,21 @@ func (ts *tester) Delete() error {
 	   kubernetes.io/namespace
 	   leegyuho-test-prod-alb-2048
 	*/
+	// tag set for matching the ALB, narrowed down by the frontend UID tag
+	// when we have one (two clusters can share a name, but never a UID).
+	albTags := map[string]string{
+		"kubernetes.io/cluster/" + ts.cfg.EKSConfig.Name: "owned",
+		"kubernetes.io/namespace":                        ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.FrontendUID != "" {
+		albTags[frontendUIDTagKey] = ts.cfg.EKSConfig.AddOnALB2048.FrontendUID
+	}
 	if err := elb.DeleteELBv2(
 		ts.cfg.Logger,
 		ts.cfg.ELB2API,
 		ts.cfg.EKSConfig.AddOnALB2048.ALBARN,
 		ts.cfg.EKSConfig.Parameters.VPCID,
-		map[string]string{
-			"kubernetes.io/cluster/" + ts.cfg.EKSConfig.Name: "owned",
-			"kubernetes.io/namespace":                        ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-		},
+		albTags,
 	); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to delete ALB (%v)", err))
 	}
@@ -242,21 +423,24 @@ func (ts *tester) Delete() error {
 
 func (ts *tester) createNamespace() error {
 	ts.cfg.Logger.Info("creating namespace", zap.String("namespace", ts.cfg.EKSConfig.AddOnALB2048.Namespace))
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		Namespaces().
-		Create(&v1.Namespace{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "v1",
-				Kind:       "Namespace",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-				Labels: map[string]string{
-					"name": ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create namespace", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Namespaces().
+			Create(&v1.Namespace{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Namespace",
 				},
-			},
-		})
+				ObjectMeta: metav1.ObjectMeta{
+					Name: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+					Labels: map[string]string{
+						"name": ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+					},
+				},
+			})
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -267,51 +451,68 @@ func (ts *tester) createNamespace() error {
 func (ts *tester) deleteNamespace() error {
 	ts.cfg.Logger.Info("deleting namespace", zap.String("namespace", ts.cfg.EKSConfig.AddOnALB2048.Namespace))
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		Namespaces().
-		Delete(
-			ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete namespace", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Namespaces().
+			Delete(
+				ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
 	if err != nil {
-		// ref. https://github.com/aws/aws-k8s-tester/issues/79
-		if !strings.Contains(err.Error(), ` not found`) {
-			return err
-		}
+		return err
 	}
-	ts.cfg.Logger.Info("deleted namespace", zap.Error(err))
+	ts.cfg.Logger.Info("deleted namespace")
 	return ts.cfg.EKSConfig.Sync()
 }
 
 // https://docs.aws.amazon.com/eks/latest/userguide/alb-ingress.html
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/rbac-role.yaml
 func (ts *tester) createALBServiceAccount() error {
+	// the ServiceAccount and its IAM role (if any) are one lifecycle unit:
+	// the role must exist before the ServiceAccount is annotated with it,
+	// and both are torn down together in deleteALBServiceAccount.
+	if err := ts.createALBServiceAccountRole(); err != nil {
+		return err
+	}
+
+	annotations := map[string]string{}
+	if ts.cfg.EKSConfig.AddOnALB2048.RoleARN != "" {
+		annotations["eks.amazonaws.com/role-arn"] = ts.cfg.EKSConfig.AddOnALB2048.RoleARN
+	}
+
 	ts.cfg.Logger.Info("creating ALB Ingress Controller  ServiceAccount")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		ServiceAccounts(albIngressControllerServiceAccountNamespace).
-		Create(&v1.ServiceAccount{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "v1",
-				Kind:       "ServiceAccount",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      albIngressControllerServiceAccountName,
-				Namespace: albIngressControllerServiceAccountNamespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/name": albIngressControllerName,
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB ServiceAccount", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			ServiceAccounts(albIngressControllerServiceAccountNamespace).
+			Create(&v1.ServiceAccount{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
 				},
-			},
-		})
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      albIngressControllerServiceAccountName,
+					Namespace: albIngressControllerServiceAccountNamespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": albIngressControllerName,
+					},
+					Annotations: annotations,
+				},
+			})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB Ingress Controller ServiceAccount (%v)", err)
 	}
 
-	ts.cfg.Logger.Info("created ALB Ingress Controller ServiceAccount")
+	ts.cfg.Logger.Info("created ALB Ingress Controller ServiceAccount",
+		zap.String("role-arn", ts.cfg.EKSConfig.AddOnALB2048.RoleARN),
+	)
 	return ts.cfg.EKSConfig.Sync()
 }
 
@@ -320,20 +521,26 @@ func (ts *tester) createALBServiceAccount() error {
 func (ts *tester) deleteALBServiceAccount() error {
 	ts.cfg.Logger.Info("deleting ALB Ingress Controller ServiceAccount")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		ServiceAccounts(albIngressControllerServiceAccountNamespace).
-		Delete(
-			albIngressControllerServiceAccountName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB ServiceAccount", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			ServiceAccounts(albIngressControllerServiceAccountNamespace).
+			Delete(
+				albIngressControllerServiceAccountName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB Ingress Controller ServiceAccount (%v)", err)
 	}
-	ts.cfg.Logger.Info("deleted ALB Ingress Controller ServiceAccount", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB Ingress Controller ServiceAccount")
+
+	if err := ts.deleteALBServiceAccountRole(); err != nil {
+		return err
+	}
 
 	return ts.cfg.EKSConfig.Sync()
 }
@@ -342,10 +549,11 @@ func (ts *tester) deleteALBServiceAccount() error {
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/rbac-role.yaml
 func (ts *tester) createALBRBACClusterRole() error {
 	ts.cfg.Logger.Info("creating ALB Ingress Controller RBAC ClusterRole")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		RbacV1().
-		ClusterRoles().
-		Create(&rbacv1.ClusterRole{
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB RBAC ClusterRole", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			RbacV1().
+			ClusterRoles().
+			Create(&rbacv1.ClusterRole{
 			TypeMeta: metav1.TypeMeta{
 				APIVersion: "rbac.authorization.k8s.io/v1",
 				Kind:       "ClusterRole",
@@ -400,6 +608,8 @@ func (ts *tester) createALBRBACClusterRole() error {
 				},
 			},
 		})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB Ingress Controller RBAC ClusterRole (%v)", err)
 	}
@@ -413,21 +623,23 @@ func (ts *tester) createALBRBACClusterRole() error {
 func (ts *tester) deleteALBRBACClusterRole() error {
 	ts.cfg.Logger.Info("deleting ALB Ingress Controller RBAC ClusterRole")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		RbacV1().
-		ClusterRoles().
-		Delete(
-			albIngressControllerRBACRoleName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB RBAC ClusterRole", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			RbacV1().
+			ClusterRoles().
+			Delete(
+				albIngressControllerRBACRoleName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB Ingress Controller RBAC ClusterRole (%v)", err)
 	}
 
-	ts.cfg.Logger.Info("deleted ALB Ingress Controller RBAC ClusterRole", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB Ingress Controller RBAC ClusterRole")
 	return ts.cfg.EKSConfig.Sync()
 }
 
@@ -435,34 +647,37 @@ func (ts *tester) deleteALBRBACClusterRole() error {
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/rbac-role.yaml
 func (ts *tester) createALBRBACClusterRoleBinding() error {
 	ts.cfg.Logger.Info("creating ALB Ingress Controller RBAC ClusterRoleBinding")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		RbacV1().
-		ClusterRoleBindings().
-		Create(&rbacv1.ClusterRoleBinding{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "rbac.authorization.k8s.io/v1",
-				Kind:       "ClusterRoleBinding",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      albIngressControllerRBACClusterRoleBindingName,
-				Namespace: albIngressControllerRBACClusterRoleBindingNamespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/name": albIngressControllerName,
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB RBAC ClusterRoleBinding", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			RbacV1().
+			ClusterRoleBindings().
+			Create(&rbacv1.ClusterRoleBinding{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRoleBinding",
 				},
-			},
-			Subjects: []rbacv1.Subject{
-				{
-					Kind:      "ServiceAccount",
-					Name:      albIngressControllerServiceAccountName,
-					Namespace: albIngressControllerServiceAccountNamespace,
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      albIngressControllerRBACClusterRoleBindingName,
+					Namespace: albIngressControllerRBACClusterRoleBindingNamespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": albIngressControllerName,
+					},
 				},
-			},
-			RoleRef: rbacv1.RoleRef{
-				APIGroup: "rbac.authorization.k8s.io",
-				Kind:     "ClusterRole",
-				Name:     albIngressControllerRBACRoleName,
-			},
-		})
+				Subjects: []rbacv1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      albIngressControllerServiceAccountName,
+						Namespace: albIngressControllerServiceAccountNamespace,
+					},
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     albIngressControllerRBACRoleName,
+				},
+			})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB Ingress Controller RBAC ClusterRoleBinding (%v)", err)
 	}
@@ -476,76 +691,80 @@ func (ts *tester) createALBRBACClusterRoleBinding() error {
 func (ts *tester) deleteALBRBACClusterRoleBinding() error {
 	ts.cfg.Logger.Info("deleting ALB Ingress Controller RBAC ClusterRoleBinding")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		RbacV1().
-		ClusterRoleBindings().
-		Delete(
-			albIngressControllerRBACClusterRoleBindingName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB RBAC ClusterRoleBinding", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			RbacV1().
+			ClusterRoleBindings().
+			Delete(
+				albIngressControllerRBACClusterRoleBindingName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB Ingress Controller RBAC ClusterRoleBinding (%v)", err)
 	}
 
-	ts.cfg.Logger.Info("deleted ALB Ingress Controller RBAC ClusterRoleBinding", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB Ingress Controller RBAC ClusterRoleBinding")
 	return ts.cfg.EKSConfig.Sync()
 }
 
 // https://docs.aws.amazon.com/eks/latest/userguide/alb-ingress.html
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/alb-ingress-controller.yaml
 func (ts *tester) createALBDeployment() error {
-	ts.cfg.Logger.Info("creating ALB Ingress Controller Deployment")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		AppsV1().
-		Deployments(albIngressControllerDeploymentNamespace).
-		Create(&appsv1.Deployment{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "apps/v1",
-				Kind:       "Deployment",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      albIngressControllerDeploymentName,
-				Namespace: albIngressControllerDeploymentNamespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/name": albIngressControllerName,
+	repo, tag := ts.albImageRepoTag()
+	ts.cfg.Logger.Info("creating ALB Ingress Controller Deployment",
+		zap.String("controller-version", ts.cfg.EKSConfig.AddOnALB2048.ControllerVersion),
+		zap.String("image-repo", repo),
+		zap.String("image-tag", tag),
+	)
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB Deployment", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(albIngressControllerDeploymentNamespace).
+			Create(&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
 				},
-			},
-			Spec: appsv1.DeploymentSpec{
-				Replicas: aws.Int32(ts.cfg.EKSConfig.AddOnALB2048.DeploymentReplicasALB),
-				Selector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      albIngressControllerDeploymentName,
+					Namespace: albIngressControllerDeploymentNamespace,
+					Labels: map[string]string{
 						"app.kubernetes.io/name": albIngressControllerName,
 					},
 				},
-				Template: v1.PodTemplateSpec{
-					ObjectMeta: metav1.ObjectMeta{
-						Labels: map[string]string{
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(ts.cfg.EKSConfig.AddOnALB2048.DeploymentReplicasALB),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
 							"app.kubernetes.io/name": albIngressControllerName,
 						},
 					},
-					Spec: v1.PodSpec{
-						Containers: []v1.Container{
-							{
-								Name:            albIngressControllerDeploymentName,
-								Image:           ALBImageName,
-								ImagePullPolicy: v1.PullAlways,
-								Args: []string{
-									"--ingress-class=alb",
-									fmt.Sprintf("--cluster-name=%s", ts.cfg.EKSConfig.Name),
-									fmt.Sprintf("--aws-vpc-id=%s", ts.cfg.EKSConfig.Parameters.VPCID),
-									fmt.Sprintf("--aws-region=%s", ts.cfg.EKSConfig.Region),
-									"-v=2", // for debugging
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": albIngressControllerName,
+							},
+						},
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Name:            albIngressControllerDeploymentName,
+									Image:           repo + ":" + tag,
+									ImagePullPolicy: v1.PullAlways,
+									Args:            ts.albControllerArgs(),
 								},
 							},
+							ServiceAccountName: albIngressControllerServiceAccountName,
 						},
-						ServiceAccountName: albIngressControllerServiceAccountName,
 					},
 				},
-			},
-		})
+			})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB Ingress Controller Deployment (%v)", err)
 	}
@@ -559,42 +778,28 @@ func (ts *tester) createALBDeployment() error {
 func (ts *tester) deleteALBDeployment() error {
 	ts.cfg.Logger.Info("deleting ALB Ingress Controller Deployment")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		AppsV1().
-		Deployments(albIngressControllerDeploymentNamespace).
-		Delete(
-			albIngressControllerDeploymentName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB Deployment", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(albIngressControllerDeploymentNamespace).
+			Delete(
+				albIngressControllerDeploymentName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB Ingress Controller Deployment (%v)", err)
 	}
 
-	ts.cfg.Logger.Info("deleted ALB Ingress Controller Deployment", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB Ingress Controller Deployment")
 	return ts.cfg.EKSConfig.Sync()
 }
 
 func (ts *tester) waitDeploymentALB() error {
 	ts.cfg.Logger.Info("waiting for ALB Deployment")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	output, err := exec.New().CommandContext(
-		ctx,
-		ts.cfg.EKSConfig.KubectlPath,
-		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
-		"--namespace="+albIngressControllerDeploymentNamespace,
-		"describe",
-		"deployment",
-		albIngressControllerDeploymentName,
-	).CombinedOutput()
-	cancel()
-	if err != nil {
-		return fmt.Errorf("'kubectl describe deployment' failed %v", err)
-	}
-	out := string(output)
-	fmt.Printf("\n\n\"kubectl describe deployment\" output:\n%s\n\n", out)
 
 	ready := false
 	waitDur := 5*time.Minute + time.Duration(ts.cfg.EKSConfig.AddOnALB2048.DeploymentReplicasALB)*time.Minute
@@ -608,10 +813,15 @@ func (ts *tester) waitDeploymentALB() error {
 		case <-time.After(15 * time.Second):
 		}
 
-		dresp, err := ts.cfg.K8SClient.KubernetesClientSet().
-			AppsV1().
-			Deployments(albIngressControllerDeploymentNamespace).
-			Get(albIngressControllerDeploymentName, metav1.GetOptions{})
+		var dresp *appsv1.Deployment
+		err := retry.GetWithRetry(ts.cfg.Logger, "get ALB Deployment", retry.DefaultConfig, func() error {
+			var getErr error
+			dresp, getErr = ts.cfg.K8SClient.KubernetesClientSet().
+				AppsV1().
+				Deployments(albIngressControllerDeploymentNamespace).
+				Get(albIngressControllerDeploymentName, metav1.GetOptions{})
+			return getErr
+		})
 		if err != nil {
 			return fmt.Errorf("failed to get Deployment (%v)", err)
 		}
@@ -655,52 +865,55 @@ func (ts *tester) waitDeploymentALB() error {
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/2048/2048-deployment.yaml
 func (ts *tester) create2048Deployment() error {
 	ts.cfg.Logger.Info("creating ALB 2048 Deployment")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		AppsV1().
-		Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-		Create(&appsv1.Deployment{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "apps/v1",
-				Kind:       "Deployment",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      alb2048DeploymentName,
-				Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-				Labels: map[string]string{
-					"app": alb2048AppName,
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB 2048 Deployment", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Create(&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
 				},
-			},
-			Spec: appsv1.DeploymentSpec{
-				Replicas: aws.Int32(ts.cfg.EKSConfig.AddOnALB2048.DeploymentReplicas2048),
-				Selector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      alb2048DeploymentName,
+					Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+					Labels: map[string]string{
 						"app": alb2048AppName,
 					},
 				},
-				Template: v1.PodTemplateSpec{
-					ObjectMeta: metav1.ObjectMeta{
-						Labels: map[string]string{
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(ts.cfg.EKSConfig.AddOnALB2048.DeploymentReplicas2048),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
 							"app": alb2048AppName,
 						},
 					},
-					Spec: v1.PodSpec{
-						Containers: []v1.Container{
-							{
-								Name:            alb2048AppName,
-								Image:           alb2048ImageName,
-								ImagePullPolicy: v1.PullAlways,
-								Ports: []v1.ContainerPort{
-									{
-										Protocol:      v1.ProtocolTCP,
-										ContainerPort: 80,
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app": alb2048AppName,
+							},
+						},
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Name:            alb2048AppName,
+									Image:           alb2048ImageName,
+									ImagePullPolicy: v1.PullAlways,
+									Ports: []v1.ContainerPort{
+										{
+											Protocol:      v1.ProtocolTCP,
+											ContainerPort: ts.containerPort(),
+										},
 									},
 								},
 							},
 						},
 					},
 				},
-			},
-		})
+			})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB 2048 Deployment (%v)", err)
 	}
@@ -714,42 +927,28 @@ func (ts *tester) create2048Deployment() error {
 func (ts *tester) delete2048Deployment() error {
 	ts.cfg.Logger.Info("deleting ALB 2048 Deployment")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		AppsV1().
-		Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-		Delete(
-			alb2048DeploymentName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB 2048 Deployment", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Delete(
+				alb2048DeploymentName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB 2048 Deployment (%v)", err)
 	}
 
-	ts.cfg.Logger.Info("deleted ALB 2048 Deployment", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB 2048 Deployment")
 	return ts.cfg.EKSConfig.Sync()
 }
 
 func (ts *tester) waitDeployment2048() error {
 	ts.cfg.Logger.Info("waiting for 2048 Deployment")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	output, err := exec.New().CommandContext(
-		ctx,
-		ts.cfg.EKSConfig.KubectlPath,
-		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
-		"--namespace="+ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-		"describe",
-		"deployment",
-		alb2048DeploymentName,
-	).CombinedOutput()
-	cancel()
-	if err != nil {
-		return fmt.Errorf("'kubectl describe deployment' failed %v", err)
-	}
-	out := string(output)
-	fmt.Printf("\n\n\"kubectl describe deployment\" output:\n%s\n\n", out)
 
 	ready := false
 	waitDur := 5*time.Minute + time.Duration(ts.cfg.EKSConfig.AddOnALB2048.DeploymentReplicas2048)*time.Minute
@@ -763,10 +962,15 @@ func (ts *tester) waitDeployment2048() error {
 		case <-time.After(15 * time.Second):
 		}
 
-		dresp, err := ts.cfg.K8SClient.KubernetesClientSet().
-			AppsV1().
-			Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-			Get(alb2048DeploymentName, metav1.GetOptions{})
+		var dresp *appsv1.Deployment
+		err := retry.GetWithRetry(ts.cfg.Logger, "get ALB 2048 Deployment", retry.DefaultConfig, func() error {
+			var getErr error
+			dresp, getErr = ts.cfg.K8SClient.KubernetesClientSet().
+				AppsV1().
+				Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+				Get(alb2048DeploymentName, metav1.GetOptions{})
+			return getErr
+		})
 		if err != nil {
 			return fmt.Errorf("failed to get Deployment (%v)", err)
 		}
@@ -810,32 +1014,35 @@ func (ts *tester) waitDeployment2048() error {
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/2048/2048-service.yaml
 func (ts *tester) create2048Service() error {
 	ts.cfg.Logger.Info("creating ALB 2048 Service")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-		Create(&v1.Service{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "v1",
-				Kind:       "Service",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      alb2048ServiceName,
-				Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-			},
-			Spec: v1.ServiceSpec{
-				Selector: map[string]string{
-					"app": alb2048AppName,
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB 2048 Service", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Create(&v1.Service{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
 				},
-				Type: v1.ServiceTypeNodePort,
-				Ports: []v1.ServicePort{
-					{
-						Protocol:   v1.ProtocolTCP,
-						Port:       80,
-						TargetPort: intstr.FromInt(80),
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      alb2048ServiceName,
+					Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+				},
+				Spec: v1.ServiceSpec{
+					Selector: map[string]string{
+						"app": alb2048AppName,
+					},
+					Type: v1.ServiceTypeNodePort,
+					Ports: []v1.ServicePort{
+						{
+							Protocol:   v1.ProtocolTCP,
+							Port:       ts.containerPort(),
+							TargetPort: intstr.FromInt(int(ts.containerPort())),
+						},
 					},
 				},
-			},
-		})
+			})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB 2048 Service (%v)", err)
 	}
@@ -849,67 +1056,67 @@ func (ts *tester) create2048Service() error {
 func (ts *tester) delete2048Service() error {
 	ts.cfg.Logger.Info("deleting ALB 2048 Service")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-		Delete(
-			alb2048ServiceName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB 2048 Service", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Delete(
+				alb2048ServiceName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB 2048 Service (%v)", err)
 	}
 
-	ts.cfg.Logger.Info("deleted ALB 2048 Service", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB 2048 Service")
 	return ts.cfg.EKSConfig.Sync()
 }
 
 // https://docs.aws.amazon.com/eks/latest/userguide/alb-ingress.html
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/2048/2048-ingress.yaml
 func (ts *tester) create2048Ingress() error {
+	if ts.cfg.EKSConfig.AddOnALB2048.MultiPathRouting {
+		if err := ts.createMultiPathBackends(); err != nil {
+			return err
+		}
+	}
+
 	ts.cfg.Logger.Info("creating ALB 2048 Ingress")
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		ExtensionsV1beta1().
-		Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-		Create(&v1beta1.Ingress{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "extensions/v1beta1",
-				Kind:       "Ingress",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      alb2048IngressName,
-				Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-				Annotations: map[string]string{
-					"kubernetes.io/ingress.class":      "alb",
-					"alb.ingress.kubernetes.io/scheme": "internet-facing",
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create ALB 2048 Ingress", retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			ExtensionsV1beta1().
+			Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Create(&v1beta1.Ingress{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "extensions/v1beta1",
+					Kind:       "Ingress",
 				},
-				Labels: map[string]string{
-					"app": alb2048AppName,
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        alb2048IngressName,
+					Namespace:   ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+					Annotations: ts.albIngressAnnotations(),
+					Labels: map[string]string{
+						"app": alb2048AppName,
+					},
 				},
-			},
-			Spec: v1beta1.IngressSpec{
-				Rules: []v1beta1.IngressRule{
-					{
-						IngressRuleValue: v1beta1.IngressRuleValue{
-							HTTP: &v1beta1.HTTPIngressRuleValue{
-								Paths: []v1beta1.HTTPIngressPath{
-									{
-										Path: "/*",
-										Backend: v1beta1.IngressBackend{
-											ServiceName: alb2048ServiceName,
-											ServicePort: intstr.FromInt(80),
-										},
-									},
+				Spec: v1beta1.IngressSpec{
+					Rules: []v1beta1.IngressRule{
+						{
+							IngressRuleValue: v1beta1.IngressRuleValue{
+								HTTP: &v1beta1.HTTPIngressRuleValue{
+									Paths: ts.ingress2048Paths(),
 								},
 							},
 						},
 					},
 				},
-			},
-		})
+			})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create ALB 2048 Ingress (%v)", err)
 	}
@@ -925,22 +1132,6 @@ func (ts *tester) create2048Ingress() error {
 	case <-time.After(waitDur):
 	}
 
-	logCmdFlags := []string{
-		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
-		"--namespace=" + albIngressControllerDeploymentNamespace,
-		"logs",
-		"--selector=" + "app.kubernetes.io/name" + "=" + albIngressControllerName,
-	}
-	css := ts.cfg.EKSConfig.KubectlPath + strings.Join(logCmdFlags, " ")
-	describeCmdFlags := []string{
-		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
-		"--namespace=" + ts.cfg.EKSConfig.AddOnALB2048.Namespace,
-		"describe",
-		"svc",
-		alb2048ServiceName,
-	}
-	dss := ts.cfg.EKSConfig.KubectlPath + strings.Join(describeCmdFlags, " ")
-
 	hostName := ""
 	waitDur = 4 * time.Minute
 	retryStart := time.Now()
@@ -953,26 +1144,8 @@ func (ts *tester) create2048Ingress() error {
 		case <-time.After(5 * time.Second):
 		}
 
-		ts.cfg.Logger.Info("fetching ALB pod logs", zap.String("cmd", css))
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		logsOutput, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, logCmdFlags...).CombinedOutput()
-		cancel()
-		out := string(logsOutput)
-		if err != nil {
-			ts.cfg.Logger.Warn("'kubectl logs alb' failed", zap.String("output", out), zap.Error(err))
-			continue
-		}
-		fmt.Printf("\n\n\n\"%s\" output:\n\n%s\n\n", css, out)
-
-		ts.cfg.Logger.Info("describing ALB service", zap.String("cmd", dss))
-		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
-		clusterInfoOut, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, describeCmdFlags...).CombinedOutput()
-		cancel()
-		if err != nil {
-			ts.cfg.Logger.Warn("'kubectl describe svc' failed", zap.Error(err))
-		}
-		out = string(clusterInfoOut)
-		fmt.Printf("\n\n\n\"%s\" output:\n\n%s\n\n", dss, out)
+		ts.fetchALBControllerPodLogs()
+		ts.describe2048Service()
 
 		ts.cfg.Logger.Info("querying ALB 2048 Ingress for HTTP endpoint")
 		so, err := ts.cfg.K8SClient.KubernetesClientSet().
@@ -1042,6 +1215,10 @@ func (ts *tester) create2048Ingress() error {
 	fmt.Printf("ALB 2048 Name %s\n", ts.cfg.EKSConfig.AddOnALB2048.ALBName)
 	fmt.Printf("ALB 2048 URL %s\n\n", ts.cfg.EKSConfig.AddOnALB2048.URL)
 
+	if err = ts.waitTargetGroupHealthy(); err != nil {
+		return err
+	}
+
 	ts.cfg.Logger.Info("waiting before testing ALB 2048 Ingress")
 	time.Sleep(10 * time.Second)
 
@@ -1077,28 +1254,344 @@ func (ts *tester) create2048Ingress() error {
 		ts.cfg.Logger.Warn("unexpected ALB 2048 Ingress output; retrying")
 	}
 
+	if ts.cfg.EKSConfig.AddOnALB2048.CertificateARN != "" {
+		ts.cfg.EKSConfig.AddOnALB2048.HTTPSURL = "https://" + hostName
+		ts.cfg.Logger.Info("waiting before testing ALB 2048 Ingress over HTTPS")
+		time.Sleep(10 * time.Second)
+
+		retryStart = time.Now()
+		for time.Now().Sub(retryStart) < waitDur {
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("ALB 2048 Ingress creation aborted")
+			case sig := <-ts.cfg.Sig:
+				return fmt.Errorf("received os signal %v", sig)
+			case <-time.After(5 * time.Second):
+			}
+
+			buf := bytes.NewBuffer(nil)
+			err = httpReadInsecure(ts.cfg.Logger, ts.cfg.EKSConfig.AddOnALB2048.HTTPSURL, buf)
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to read ALB 2048 Service over HTTPS; retrying", zap.Error(err))
+				time.Sleep(5 * time.Second)
+				continue
+			}
+
+			httpsOutput := buf.String()
+			fmt.Printf("\nALB 2048 Ingress HTTPS output:\n%s\n", httpsOutput)
+
+			if strings.Contains(httpsOutput, `2048 tile!`) {
+				ts.cfg.Logger.Info(
+					"read ALB 2048 Service over HTTPS; exiting",
+					zap.String("host-name", hostName),
+				)
+				break
+			}
+
+			ts.cfg.Logger.Warn("unexpected ALB 2048 Ingress HTTPS output; retrying")
+		}
+	}
+
+	if ts.cfg.EKSConfig.AddOnALB2048.MultiPathRouting {
+		if err = ts.verifyMultiPathRouting(ts.cfg.EKSConfig.AddOnALB2048.URL); err != nil {
+			return err
+		}
+	}
+
+	if err = ts.runLoadTest(ts.cfg.EKSConfig.AddOnALB2048.URL); err != nil {
+		return err
+	}
+
 	return ts.cfg.EKSConfig.Sync()
 }
 
+// albIngressMatrixEntry is one {scheme, target-type} combination in the
+// internal/internet-facing x instance/ip coverage matrix. AddOnALB2048.IngressMatrix
+// opts a run into creating one Ingress per entry against the existing 2048
+// Service, in addition to the single internet-facing Ingress create2048Ingress
+// always provisions.
+type albIngressMatrixEntry struct {
+	scheme     string
+	targetType string
+}
+
+func albIngressMatrix() []albIngressMatrixEntry {
+	return []albIngressMatrixEntry{
+		{scheme: "internal", targetType: "instance"},
+		{scheme: "internal", targetType: "ip"},
+		{scheme: "internet-facing", targetType: "instance"},
+		{scheme: "internet-facing", targetType: "ip"},
+	}
+}
+
+func (e albIngressMatrixEntry) key() string {
+	return e.scheme + "/" + e.targetType
+}
+
+func (e albIngressMatrixEntry) ingressName() string {
+	return fmt.Sprintf("%s-%s-%s", alb2048IngressName, e.scheme, e.targetType)
+}
+
+type albIngressMatrixResult struct {
+	entry    albIngressMatrixEntry
+	hostName string
+	arn      string
+	err      error
+}
+
+// create2048IngressMatrixEntry creates a single matrix Ingress pointed at the
+// shared 2048 Service, waits for its ALB hostname, describes the ALB for its
+// ARN, and verifies it serves the 2048 app.
+func (ts *tester) create2048IngressMatrixEntry(e albIngressMatrixEntry) (hostName string, arn string, err error) {
+	name := e.ingressName()
+	ts.cfg.Logger.Info("creating ALB 2048 Ingress matrix entry",
+		zap.String("name", name),
+		zap.String("scheme", e.scheme),
+		zap.String("target-type", e.targetType),
+	)
+	err = retry.CreateWithRetry(ts.cfg.Logger, "create ALB 2048 Ingress "+name, retry.DefaultConfig, func() error {
+		_, cerr := ts.cfg.K8SClient.KubernetesClientSet().
+			ExtensionsV1beta1().
+			Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Create(&v1beta1.Ingress{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "extensions/v1beta1",
+					Kind:       "Ingress",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+					Annotations: map[string]string{
+						"kubernetes.io/ingress.class":           "alb",
+						"alb.ingress.kubernetes.io/scheme":      e.scheme,
+						"alb.ingress.kubernetes.io/target-type": e.targetType,
+						"alb.ingress.kubernetes.io/tags":        fmt.Sprintf("%s=%s", frontendUIDTagKey, ts.cfg.EKSConfig.AddOnALB2048.FrontendUID),
+					},
+					Labels: map[string]string{
+						"app": alb2048AppName,
+					},
+				},
+				Spec: v1beta1.IngressSpec{
+					Rules: []v1beta1.IngressRule{
+						{
+							IngressRuleValue: v1beta1.IngressRuleValue{
+								HTTP: &v1beta1.HTTPIngressRuleValue{
+									Paths: []v1beta1.HTTPIngressPath{
+										{
+											Path: "/*",
+											Backend: v1beta1.IngressBackend{
+												ServiceName: alb2048ServiceName,
+												ServicePort: intstr.FromInt(int(ts.containerPort())),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			})
+		return cerr
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create ALB 2048 Ingress %q (%v)", name, err)
+	}
+
+	waitDur := 4 * time.Minute
+	retryStart := time.Now()
+	for hostName == "" && time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return "", "", errors.New("ALB 2048 Ingress matrix creation aborted")
+		case sig := <-ts.cfg.Sig:
+			return "", "", fmt.Errorf("received os signal %v", sig)
+		case <-time.After(5 * time.Second):
+		}
+
+		so, gerr := ts.cfg.K8SClient.KubernetesClientSet().
+			ExtensionsV1beta1().
+			Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Get(name, metav1.GetOptions{})
+		if gerr != nil {
+			ts.cfg.Logger.Warn("failed to get ALB 2048 Ingress matrix entry; retrying", zap.String("name", name), zap.Error(gerr))
+			continue
+		}
+		for _, ing := range so.Status.LoadBalancer.Ingress {
+			hostName = ing.Hostname
+			break
+		}
+	}
+	if hostName == "" {
+		return "", "", fmt.Errorf("failed to find ALB host name for Ingress %q", name)
+	}
+
+	albName := ""
+	fields := strings.Split(hostName, "-")
+	if len(fields) >= 3 {
+		albName = strings.Join(fields[:3], "-")
+	}
+	if albName == "" {
+		return hostName, "", fmt.Errorf("failed to derive ALB name for Ingress %q host name %q", name, hostName)
+	}
+	do, err := ts.cfg.ELB2API.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
+		Names: aws.StringSlice([]string{albName}),
+	})
+	if err != nil {
+		return hostName, "", err
+	}
+	for _, lb := range do.LoadBalancers {
+		arn = aws.StringValue(lb.LoadBalancerArn)
+		break
+	}
+
+	url := "http://" + hostName
+	probeStart := time.Now()
+	for time.Now().Sub(probeStart) < waitDur {
+		buf := bytes.NewBuffer(nil)
+		if perr := httpReadInsecure(ts.cfg.Logger, url, buf); perr == nil && strings.Contains(buf.String(), `2048 tile!`) {
+			ts.cfg.Logger.Info("verified ALB 2048 Ingress matrix entry", zap.String("name", name), zap.String("host-name", hostName))
+			return hostName, arn, nil
+		}
+		time.Sleep(5 * time.Second)
+	}
+	return hostName, arn, fmt.Errorf("failed to verify ALB 2048 Ingress matrix entry %q", name)
+}
+
+// create2048IngressMatrix fans out one Ingress per albIngressMatrix entry,
+// verifies each in parallel, and records the resulting hostname/ARN under
+// AddOnALB2048.Ingresses/IngressARNs, keyed by "<scheme>/<target-type>".
+func (ts *tester) create2048IngressMatrix() error {
+	matrix := albIngressMatrix()
+	ch := make(chan albIngressMatrixResult, len(matrix))
+	for _, e := range matrix {
+		e := e
+		go func() {
+			hostName, arn, err := ts.create2048IngressMatrixEntry(e)
+			ch <- albIngressMatrixResult{entry: e, hostName: hostName, arn: arn, err: err}
+		}()
+	}
+
+	if ts.cfg.EKSConfig.AddOnALB2048.Ingresses == nil {
+		ts.cfg.EKSConfig.AddOnALB2048.Ingresses = make(map[string]string)
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.IngressARNs == nil {
+		ts.cfg.EKSConfig.AddOnALB2048.IngressARNs = make(map[string]string)
+	}
+
+	var errs []string
+	for range matrix {
+		r := <-ch
+		if r.err != nil {
+			errs = append(errs, fmt.Sprintf("%s (%v)", r.entry.key(), r.err))
+			continue
+		}
+		ts.cfg.EKSConfig.AddOnALB2048.Ingresses[r.entry.key()] = "http://" + r.hostName
+		ts.cfg.EKSConfig.AddOnALB2048.IngressARNs[r.entry.key()] = r.arn
+	}
+	close(ch)
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to create ALB 2048 Ingress matrix: %s", strings.Join(errs, "; "))
+	}
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) delete2048IngressMatrix() error {
+	var errs []string
+	for _, e := range albIngressMatrix() {
+		name := e.ingressName()
+		foreground := metav1.DeletePropagationForeground
+		err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB 2048 Ingress "+name, retry.DefaultConfig, func() error {
+			return ts.cfg.K8SClient.KubernetesClientSet().
+				ExtensionsV1beta1().
+				Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+				Delete(name, &metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				})
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete ALB 2048 Ingress %q (%v)", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// fetchALBControllerPodLogs logs a tail of the ALB Ingress Controller pod
+// logs via client-go, rather than shelling out to "kubectl logs", so the
+// tester still works when kubectl isn't on PATH.
+func (ts *tester) fetchALBControllerPodLogs() {
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(albIngressControllerDeploymentNamespace).
+		List(metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + albIngressControllerName})
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to list ALB Ingress Controller Pods", zap.Error(err))
+		return
+	}
+	for _, pod := range pods.Items {
+		stream, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(albIngressControllerDeploymentNamespace).
+			GetLogs(pod.Name, &v1.PodLogOptions{TailLines: aws.Int64(50)}).
+			Stream()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to stream ALB Ingress Controller Pod logs", zap.String("pod", pod.Name), zap.Error(err))
+			continue
+		}
+		out, err := io.ReadAll(stream)
+		stream.Close()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to read ALB Ingress Controller Pod logs", zap.String("pod", pod.Name), zap.Error(err))
+			continue
+		}
+		fmt.Printf("\n\n\n\"%s\" logs:\n\n%s\n\n", pod.Name, string(out))
+	}
+}
+
+// describe2048Service logs the ALB 2048 Service object via client-go, rather
+// than shelling out to "kubectl describe svc".
+func (ts *tester) describe2048Service() {
+	svc, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+		Get(alb2048ServiceName, metav1.GetOptions{})
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to get ALB 2048 Service", zap.Error(err))
+		return
+	}
+	fmt.Printf("\n\n\n\"%s\" Service:\n\n%+v\n\n", alb2048ServiceName, svc)
+}
+
 // https://docs.aws.amazon.com/eks/latest/userguide/alb-ingress.html
 // https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/2048/2048-ingress.yaml
 func (ts *tester) delete2048Ingress() error {
 	ts.cfg.Logger.Info("deleting ALB 2048 Ingress")
 	foreground := metav1.DeletePropagationForeground
-	err := ts.cfg.K8SClient.KubernetesClientSet().
-		ExtensionsV1beta1().
-		Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
-		Delete(
-			alb2048IngressName,
-			&metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &foreground,
-			},
-		)
-	if err != nil && !strings.Contains(err.Error(), " not found") {
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete ALB 2048 Ingress", retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			ExtensionsV1beta1().
+			Ingresses(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Delete(
+				alb2048IngressName,
+				&metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete ALB 2048 Ingress (%v)", err)
 	}
-	ts.cfg.Logger.Info("deleted ALB 2048 Ingress", zap.Error(err))
+	ts.cfg.Logger.Info("deleted ALB 2048 Ingress")
+
+	if ts.cfg.EKSConfig.AddOnALB2048.MultiPathRouting {
+		if err := ts.deleteMultiPathBackends(); err != nil {
+			return err
+		}
+	}
 
 	return ts.cfg.EKSConfig.Sync()
 }
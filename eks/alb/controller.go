@@ -0,0 +1,175 @@
+package alb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+const (
+	albControllerV1ImageRepo = "docker.io/amazon/aws-alb-ingress-controller"
+	albControllerV1ImageTag  = "v1.1.5"
+
+	albControllerV2ImageRepo = "docker.io/amazon/aws-load-balancer-controller"
+	albControllerV2ImageTag  = "v2.4.1"
+
+	// albControllerV2CRDManifestURL installs the IngressClassParams and
+	// TargetGroupBinding CRDs the v2 controller (and its IngressClass) depend
+	// on, since client-go has no typed client for CustomResourceDefinitions.
+	albControllerV2CRDManifestURL = "https://raw.githubusercontent.com/kubernetes-sigs/aws-load-balancer-controller/v2.4.1/helm/aws-load-balancer-controller/crds/crds.yaml"
+
+	albIngressClassName = "alb"
+)
+
+// albIngressClassManifest is the IngressClass CR the v2 controller watches,
+// since "kubernetes.io/ingress.class: alb" (the v1 annotation) is ignored by
+// the v2 controller unless paired with a matching IngressClass object.
+const albIngressClassManifest = `
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: ` + albIngressClassName + `
+spec:
+  controller: ingress.k8s.aws/alb
+`
+
+// albImageRepoTag returns the ALB Ingress Controller image repository and tag
+// to run, honoring AddOnALB2048.ControllerImageRepo/ControllerImageTag
+// overrides (e.g. to point at an ECR mirror or a custom build) and otherwise
+// falling back to the default image for AddOnALB2048.ControllerVersion.
+func (ts *tester) albImageRepoTag() (repo string, tag string) {
+	repo, tag = albControllerV1ImageRepo, albControllerV1ImageTag
+	if ts.cfg.EKSConfig.AddOnALB2048.ControllerVersion == "v2" {
+		repo, tag = albControllerV2ImageRepo, albControllerV2ImageTag
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.ControllerImageRepo != "" {
+		repo = ts.cfg.EKSConfig.AddOnALB2048.ControllerImageRepo
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.ControllerImageTag != "" {
+		tag = ts.cfg.EKSConfig.AddOnALB2048.ControllerImageTag
+	}
+	return repo, tag
+}
+
+// albControllerArgs returns the CLI args for the ALB Ingress Controller
+// Deployment container. The legacy v1 controller and the upstream AWS Load
+// Balancer Controller v2 get the same flags (--cluster-name, --aws-vpc-id,
+// --aws-region, --ingress-class=alb, -v=2), only in a different order; no
+// v2-only flags (e.g. webhook certificate settings) are passed.
+func (ts *tester) albControllerArgs() []string {
+	if ts.cfg.EKSConfig.AddOnALB2048.ControllerVersion == "v2" {
+		return []string{
+			fmt.Sprintf("--cluster-name=%s", ts.cfg.EKSConfig.Name),
+			fmt.Sprintf("--aws-vpc-id=%s", ts.cfg.EKSConfig.Parameters.VPCID),
+			fmt.Sprintf("--aws-region=%s", ts.cfg.EKSConfig.Region),
+			"--ingress-class=alb",
+			"-v=2", // for debugging
+		}
+	}
+	return []string{
+		"--ingress-class=alb",
+		fmt.Sprintf("--cluster-name=%s", ts.cfg.EKSConfig.Name),
+		fmt.Sprintf("--aws-vpc-id=%s", ts.cfg.EKSConfig.Parameters.VPCID),
+		fmt.Sprintf("--aws-region=%s", ts.cfg.EKSConfig.Region),
+		"-v=2", // for debugging
+	}
+}
+
+// installALBControllerCRDs installs the CRDs (TargetGroupBinding,
+// IngressClassParams) and the IngressClass CR the v2 controller requires.
+// The v1 controller needs neither, since it predates both.
+func (ts *tester) installALBControllerCRDs() error {
+	if ts.cfg.EKSConfig.AddOnALB2048.ControllerVersion != "v2" {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("installing AWS Load Balancer Controller v2 CRDs", zap.String("manifest", albControllerV2CRDManifestURL))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"apply",
+		"-f",
+		albControllerV2CRDManifestURL,
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' AWS Load Balancer Controller v2 CRDs failed (output %q, error %v)", string(output), err)
+	}
+	ts.cfg.Logger.Info("installed AWS Load Balancer Controller v2 CRDs")
+
+	return ts.kubectlApplyInline("alb-ingress-class", albIngressClassManifest)
+}
+
+func (ts *tester) deleteALBControllerCRDs() error {
+	if ts.cfg.EKSConfig.AddOnALB2048.ControllerVersion != "v2" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"ingressclass",
+		albIngressClassName,
+		"--ignore-not-found",
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl delete' IngressClass %q failed (output %q, error %v)", albIngressClassName, string(output), err)
+	}
+
+	ts.cfg.Logger.Info("deleting AWS Load Balancer Controller v2 CRDs", zap.String("manifest", albControllerV2CRDManifestURL))
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Minute)
+	output, err = exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"-f",
+		albControllerV2CRDManifestURL,
+		"--ignore-not-found",
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl delete' AWS Load Balancer Controller v2 CRDs failed (output %q, error %v)", string(output), err)
+	}
+	ts.cfg.Logger.Info("deleted AWS Load Balancer Controller v2 CRDs")
+	return nil
+}
+
+// kubectlApplyInline writes manifest to a temp file and "kubectl apply -f"s
+// it, since client-go has no typed client for arbitrary CRs like IngressClass.
+func (ts *tester) kubectlApplyInline(label, manifest string) error {
+	f, err := os.CreateTemp("", "alb-"+label+"-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(manifest); err != nil {
+		return err
+	}
+	f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"apply",
+		"-f",
+		f.Name(),
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' %s failed (output %q, error %v)", label, string(output), err)
+	}
+	return nil
+}
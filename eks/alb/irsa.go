@@ -0,0 +1,207 @@
+package alb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awscfn "github.com/aws/aws-k8s-tester/pkg/aws/cloudformation"
+	"github.com/aws/aws-k8s-tester/version"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"go.uber.org/zap"
+)
+
+// TemplateALBServiceAccountRole is the CloudFormation template for the IAM
+// role the ALB Ingress Controller's ServiceAccount assumes via IRSA, instead
+// of inheriting permissions from the underlying node instance profile.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/iam-roles-for-service-accounts.html
+const TemplateALBServiceAccountRole = `
+---
+AWSTemplateFormatVersion: '2010-09-09'
+Description: 'IAM role for the ALB Ingress Controller ServiceAccount (IRSA)'
+
+Parameters:
+
+  ClusterOIDCProviderARN:
+    Type: String
+    Description: The ARN of the cluster's IAM OIDC identity provider.
+
+  ClusterOIDCProviderURL:
+    Type: String
+    Description: The OIDC issuer URL of the cluster, without the leading "https://".
+
+  ServiceAccountNamespace:
+    Type: String
+    Description: The Namespace of the ALB Ingress Controller ServiceAccount.
+
+  ServiceAccountName:
+    Type: String
+    Description: The Name of the ALB Ingress Controller ServiceAccount.
+
+  PolicyARN:
+    Type: String
+    Description: The managed IAM policy ARN granting ALB Ingress Controller permissions.
+
+Resources:
+
+  ALBServiceAccountRole:
+    Type: AWS::IAM::Role
+    Properties:
+      AssumeRolePolicyDocument:
+        Version: '2012-10-17'
+        Statement:
+        - Effect: Allow
+          Principal:
+            Federated: !Ref ClusterOIDCProviderARN
+          Action: sts:AssumeRoleWithWebIdentity
+          Condition:
+            StringEquals:
+              !Sub '${ClusterOIDCProviderURL}:sub': !Sub 'system:serviceaccount:${ServiceAccountNamespace}:${ServiceAccountName}'
+      ManagedPolicyArns:
+      - !Ref PolicyARN
+
+Outputs:
+
+  ALBServiceAccountRoleARN:
+    Value: !GetAtt ALBServiceAccountRole.Arn
+    Description: The IAM role ARN assumed by the ALB Ingress Controller ServiceAccount
+
+`
+
+// createALBServiceAccountRole provisions (via CFN) the IAM role the ALB
+// Ingress Controller ServiceAccount assumes through IRSA, and persists both
+// the CFN stack ID and the resulting role ARN so Delete can clean it up and
+// the ServiceAccount annotation survives a restart.
+func (ts *tester) createALBServiceAccountRole() error {
+	if !ts.cfg.EKSConfig.AddOnALB2048.RoleCreate {
+		ts.cfg.Logger.Info("AddOnALB2048.RoleCreate false; skipping ALB ServiceAccount IAM role creation",
+			zap.String("role-arn", ts.cfg.EKSConfig.AddOnALB2048.RoleARN),
+		)
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID != "" {
+		ts.cfg.Logger.Info("ALB ServiceAccount IAM role already created; no need to create a new one")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("creating ALB ServiceAccount IAM role via CFN")
+	stackInput := &cloudformation.CreateStackInput{
+		StackName:    aws.String(ts.cfg.EKSConfig.Name + "-alb-sa-role"),
+		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM", "CAPABILITY_IAM"}),
+		OnFailure:    aws.String(cloudformation.OnFailureDelete),
+		TemplateBody: aws.String(TemplateALBServiceAccountRole),
+		Tags: awscfn.NewTags(map[string]string{
+			"Kind":                   "aws-k8s-tester",
+			"Name":                   ts.cfg.EKSConfig.Name,
+			"aws-k8s-tester-version": version.ReleaseVersion,
+		}),
+		Parameters: []*cloudformation.Parameter{
+			{
+				ParameterKey:   aws.String("ClusterOIDCProviderARN"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.Status.ClusterOIDCProviderARN),
+			},
+			{
+				ParameterKey:   aws.String("ClusterOIDCProviderURL"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.Status.ClusterOIDCProviderURL),
+			},
+			{
+				ParameterKey:   aws.String("ServiceAccountNamespace"),
+				ParameterValue: aws.String(albIngressControllerServiceAccountNamespace),
+			},
+			{
+				ParameterKey:   aws.String("ServiceAccountName"),
+				ParameterValue: aws.String(albIngressControllerServiceAccountName),
+			},
+			{
+				ParameterKey:   aws.String("PolicyARN"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.AddOnALB2048.RoleManagedPolicyARNs[0]),
+			},
+		},
+	}
+	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID = aws.StringValue(stackOutput.StackId)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ch := awscfn.Poll(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Sig,
+		ts.cfg.Logger,
+		ts.cfg.CFNAPI,
+		ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID,
+		cloudformation.ResourceStatusCreateComplete,
+		time.Minute,
+		10*time.Second,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			cancel()
+			ts.cfg.EKSConfig.RecordStatus(fmt.Sprintf("failed to create ALB ServiceAccount IAM role (%v)", st.Error))
+			return st.Error
+		}
+	}
+	cancel()
+
+	for _, o := range st.Stack.Outputs {
+		switch k := aws.StringValue(o.OutputKey); k {
+		case "ALBServiceAccountRoleARN":
+			ts.cfg.EKSConfig.AddOnALB2048.RoleARN = aws.StringValue(o.OutputValue)
+		default:
+			return fmt.Errorf("unexpected OutputKey %q from %q", k, ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID)
+		}
+	}
+
+	ts.cfg.Logger.Info("created ALB ServiceAccount IAM role",
+		zap.String("role-arn", ts.cfg.EKSConfig.AddOnALB2048.RoleARN),
+	)
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) deleteALBServiceAccountRole() error {
+	if !ts.cfg.EKSConfig.AddOnALB2048.RoleCreate {
+		ts.cfg.Logger.Info("AddOnALB2048.RoleCreate false; no need to delete ALB ServiceAccount IAM role")
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID == "" {
+		ts.cfg.Logger.Info("empty ALB ServiceAccount IAM role CFN stack ID; no need to delete")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting ALB ServiceAccount IAM role CFN stack",
+		zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID),
+	)
+	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+		StackName: aws.String(ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID),
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ch := awscfn.Poll(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Sig,
+		ts.cfg.Logger,
+		ts.cfg.CFNAPI,
+		ts.cfg.EKSConfig.AddOnALB2048.RoleCFNStackID,
+		cloudformation.ResourceStatusDeleteComplete,
+		time.Minute,
+		10*time.Second,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			cancel()
+			ts.cfg.EKSConfig.RecordStatus(fmt.Sprintf("failed to delete ALB ServiceAccount IAM role (%v)", st.Error))
+			return st.Error
+		}
+	}
+	cancel()
+	ts.cfg.Logger.Info("deleted ALB ServiceAccount IAM role")
+	return ts.cfg.EKSConfig.Sync()
+}
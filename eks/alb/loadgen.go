@@ -0,0 +1,150 @@
+package alb
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// loadTestResult is the summary persisted to
+// AddOnALB2048.LoadTestResultsJSON once runLoadTest finishes.
+type loadTestResult struct {
+	RequestsPerSecond int         `json:"requests_per_second"`
+	Concurrency       int         `json:"concurrency"`
+	Duration          string      `json:"duration"`
+	TotalRequests     int         `json:"total_requests"`
+	ErrorCount        int         `json:"error_count"`
+	ErrorRate         float64     `json:"error_rate"`
+	StatusCounts      map[int]int `json:"status_counts"`
+	LatencyP50Ms      float64     `json:"latency_p50_ms"`
+	LatencyP90Ms      float64     `json:"latency_p90_ms"`
+	LatencyP99Ms      float64     `json:"latency_p99_ms"`
+}
+
+type loadTestSample struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// runLoadTest issues GETs against url at AddOnALB2048.RequestsPerSecond for
+// AddOnALB2048.Duration across AddOnALB2048.Concurrency workers, reusing
+// httpReadInsecure's TLS config but with keepalives enabled so the client
+// behaves like a real ALB consumer, then persists the latency/error-rate/
+// status-code summary. A no-op if any of the three knobs is unset, since
+// this phase is opt-in on top of the single-request tile check.
+func (ts *tester) runLoadTest(url string) error {
+	rps := ts.cfg.EKSConfig.AddOnALB2048.RequestsPerSecond
+	dur := ts.cfg.EKSConfig.AddOnALB2048.Duration
+	concurrency := ts.cfg.EKSConfig.AddOnALB2048.Concurrency
+	if rps <= 0 || dur <= 0 || concurrency <= 0 {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting ALB 2048 load test",
+		zap.String("url", url),
+		zap.Int("requests-per-second", rps),
+		zap.Duration("duration", dur),
+		zap.Int("concurrency", concurrency),
+	)
+
+	cli := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+			MaxIdleConnsPerHost: concurrency,
+			DisableKeepAlives:   false,
+		},
+	}
+
+	samplesc := make(chan loadTestSample, rps*int(dur/time.Second+1))
+	tick := time.NewTicker(time.Second / time.Duration(rps))
+	defer tick.Stop()
+	stop := time.After(dur)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+loop:
+	for {
+		select {
+		case <-stop:
+			break loop
+		case <-ts.cfg.Stopc:
+			break loop
+		case <-tick.C:
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				start := time.Now()
+				resp, err := cli.Get(url)
+				latency := time.Since(start)
+				if err != nil {
+					samplesc <- loadTestSample{latency: latency, err: err}
+					return
+				}
+				resp.Body.Close()
+				samplesc <- loadTestSample{latency: latency, status: resp.StatusCode}
+			}()
+		}
+	}
+	wg.Wait()
+	close(samplesc)
+
+	result := loadTestResult{
+		RequestsPerSecond: rps,
+		Concurrency:       concurrency,
+		Duration:          dur.String(),
+		StatusCounts:      make(map[int]int),
+	}
+	latencies := make([]time.Duration, 0, len(samplesc))
+	for s := range samplesc {
+		result.TotalRequests++
+		if s.err != nil {
+			result.ErrorCount++
+			continue
+		}
+		result.StatusCounts[s.status]++
+		if s.status >= 400 {
+			result.ErrorCount++
+		}
+		latencies = append(latencies, s.latency)
+	}
+	if result.TotalRequests > 0 {
+		result.ErrorRate = float64(result.ErrorCount) / float64(result.TotalRequests)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.LatencyP50Ms = latencyPercentileMs(latencies, 0.50)
+	result.LatencyP90Ms = latencyPercentileMs(latencies, 0.90)
+	result.LatencyP99Ms = latencyPercentileMs(latencies, 0.99)
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.AddOnALB2048.LoadTestResultsJSON = string(b)
+	ts.cfg.Logger.Info("finished ALB 2048 load test",
+		zap.Int("total-requests", result.TotalRequests),
+		zap.Int("error-count", result.ErrorCount),
+		zap.Float64("error-rate", result.ErrorRate),
+		zap.Float64("p50-ms", result.LatencyP50Ms),
+		zap.Float64("p90-ms", result.LatencyP90Ms),
+		zap.Float64("p99-ms", result.LatencyP99Ms),
+	)
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func latencyPercentileMs(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return float64(sorted[idx]) / float64(time.Millisecond)
+}
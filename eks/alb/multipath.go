@@ -0,0 +1,257 @@
+package alb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/k8sclient/retry"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	v1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	alb2048HealthzAppName        = "alb-2048-healthz"
+	alb2048HealthzImageName      = "nginx:1.21"
+	alb2048HealthzDeploymentName = "alb-2048-healthz-deployment"
+	alb2048HealthzServiceName    = "alb-2048-healthz-service"
+
+	alb2048APIAppName        = "alb-2048-api"
+	alb2048APIImageName      = "k8s.gcr.io/echoserver:1.10"
+	alb2048APIDeploymentName = "alb-2048-api-deployment"
+	alb2048APIServiceName    = "alb-2048-api-service"
+)
+
+// multiPathBackend is one additional Deployment+Service the
+// MultiPathRouting Ingress rule set routes to alongside the primary 2048
+// backend, so a single Ingress can exercise ALB rule-count and
+// path-priority ordering.
+type multiPathBackend struct {
+	path           string
+	appName        string
+	image          string
+	deploymentName string
+	serviceName    string
+}
+
+func multiPathBackends() []multiPathBackend {
+	return []multiPathBackend{
+		{path: "/healthz", appName: alb2048HealthzAppName, image: alb2048HealthzImageName, deploymentName: alb2048HealthzDeploymentName, serviceName: alb2048HealthzServiceName},
+		{path: "/api/*", appName: alb2048APIAppName, image: alb2048APIImageName, deploymentName: alb2048APIDeploymentName, serviceName: alb2048APIServiceName},
+	}
+}
+
+// multiPathIngressRules returns the "/game/*" rule for the primary 2048
+// backend plus one rule per multiPathBackends() entry.
+func (ts *tester) multiPathIngressRules() []v1beta1.HTTPIngressPath {
+	paths := []v1beta1.HTTPIngressPath{
+		{
+			Path: "/game/*",
+			Backend: v1beta1.IngressBackend{
+				ServiceName: alb2048ServiceName,
+				ServicePort: intstr.FromInt(int(ts.containerPort())),
+			},
+		},
+	}
+	for _, b := range multiPathBackends() {
+		paths = append(paths, v1beta1.HTTPIngressPath{
+			Path: b.path,
+			Backend: v1beta1.IngressBackend{
+				ServiceName: b.serviceName,
+				ServicePort: intstr.FromInt(80),
+			},
+		})
+	}
+	return paths
+}
+
+// createMultiPathBackends provisions a single-replica Deployment+Service for
+// each multiPathBackends() entry.
+func (ts *tester) createMultiPathBackends() error {
+	for _, b := range multiPathBackends() {
+		if err := ts.createSimpleDeployment(b.deploymentName, b.appName, b.image); err != nil {
+			return err
+		}
+		if err := ts.createSimpleService(b.serviceName, b.appName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *tester) deleteMultiPathBackends() error {
+	var errs []string
+	for _, b := range multiPathBackends() {
+		if err := ts.deleteSimpleService(b.serviceName); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := ts.deleteSimpleDeployment(b.deploymentName); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// verifyMultiPathRouting probes each multiPathBackends() path against
+// baseURL and requires a non-error HTTP response.
+func (ts *tester) verifyMultiPathRouting(baseURL string) error {
+	for _, b := range multiPathBackends() {
+		path := strings.TrimSuffix(strings.TrimSuffix(b.path, "*"), "/")
+		url := baseURL + path
+		ts.cfg.Logger.Info("verifying multi-path route", zap.String("path", b.path), zap.String("url", url))
+
+		var err error
+		retryStart := time.Now()
+		for time.Now().Sub(retryStart) < time.Minute {
+			buf := bytes.NewBuffer(nil)
+			if err = httpReadInsecure(ts.cfg.Logger, url, buf); err == nil {
+				break
+			}
+			time.Sleep(5 * time.Second)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to verify multi-path route %q (%v)", b.path, err)
+		}
+	}
+	return nil
+}
+
+func (ts *tester) createSimpleDeployment(name, appName, image string) error {
+	ts.cfg.Logger.Info("creating multi-path backend Deployment", zap.String("name", name))
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create "+name, retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Create(&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+					Labels: map[string]string{
+						"app": appName,
+					},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(1),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app": appName,
+						},
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app": appName,
+							},
+						},
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           image,
+									ImagePullPolicy: v1.PullAlways,
+									Ports: []v1.ContainerPort{
+										{
+											Protocol:      v1.ProtocolTCP,
+											ContainerPort: 80,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s (%v)", name, err)
+	}
+	ts.cfg.Logger.Info("created multi-path backend Deployment", zap.String("name", name))
+	return nil
+}
+
+func (ts *tester) deleteSimpleDeployment(name string) error {
+	foreground := metav1.DeletePropagationForeground
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete "+name, retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Delete(name, &metav1.DeleteOptions{
+				GracePeriodSeconds: aws.Int64(0),
+				PropagationPolicy:  &foreground,
+			})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s (%v)", name, err)
+	}
+	return nil
+}
+
+func (ts *tester) createSimpleService(name, appName string) error {
+	ts.cfg.Logger.Info("creating multi-path backend Service", zap.String("name", name))
+	err := retry.CreateWithRetry(ts.cfg.Logger, "create "+name, retry.DefaultConfig, func() error {
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Create(&v1.Service{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.EKSConfig.AddOnALB2048.Namespace,
+				},
+				Spec: v1.ServiceSpec{
+					Selector: map[string]string{
+						"app": appName,
+					},
+					Type: v1.ServiceTypeNodePort,
+					Ports: []v1.ServicePort{
+						{
+							Protocol:   v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s (%v)", name, err)
+	}
+	ts.cfg.Logger.Info("created multi-path backend Service", zap.String("name", name))
+	return nil
+}
+
+func (ts *tester) deleteSimpleService(name string) error {
+	foreground := metav1.DeletePropagationForeground
+	err := retry.DeleteWithRetry(ts.cfg.Logger, "delete "+name, retry.DefaultConfig, func() error {
+		return ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Services(ts.cfg.EKSConfig.AddOnALB2048.Namespace).
+			Delete(name, &metav1.DeleteOptions{
+				GracePeriodSeconds: aws.Int64(0),
+				PropagationPolicy:  &foreground,
+			})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s (%v)", name, err)
+	}
+	return nil
+}
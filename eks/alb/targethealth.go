@@ -0,0 +1,123 @@
+package alb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"go.uber.org/zap"
+)
+
+// targetHealthSnapshot is the per-target-group health state persisted to
+// AddOnALB2048.TargetHealthJSON.
+type targetHealthSnapshot struct {
+	TargetGroupARN string              `json:"target_group_arn"`
+	Targets        []targetHealthEntry `json:"targets"`
+}
+
+type targetHealthEntry struct {
+	TargetID    string `json:"target_id"`
+	Port        int64  `json:"port"`
+	State       string `json:"state"`
+	Reason      string `json:"reason,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// waitTargetGroupHealthy requires every target registered to every target
+// group fronting AddOnALB2048.ALBARN to reach "healthy", since an ALB
+// hostname resolving to an HTTP 200 doesn't by itself rule out partial
+// target registration.
+func (ts *tester) waitTargetGroupHealthy() error {
+	waitDur := 3 * time.Minute
+	retryStart := time.Now()
+	for {
+		snapshots, allHealthy, err := ts.describeTargetHealth()
+		if err != nil {
+			return err
+		}
+		if allHealthy {
+			b, jerr := json.Marshal(snapshots)
+			if jerr != nil {
+				return jerr
+			}
+			ts.cfg.EKSConfig.AddOnALB2048.TargetHealthJSON = string(b)
+			ts.cfg.Logger.Info("all ALB 2048 targets healthy", zap.Int("target-groups", len(snapshots)))
+			return ts.cfg.EKSConfig.Sync()
+		}
+		if time.Now().Sub(retryStart) >= waitDur {
+			return errors.New("timed out waiting for ALB 2048 targets to become healthy")
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("ALB 2048 target health check aborted")
+		case sig := <-ts.cfg.Sig:
+			return fmt.Errorf("received os signal %v", sig)
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// describeTargetHealth calls DescribeTargetGroups for AddOnALB2048.ALBARN
+// and DescribeTargetHealth for each, logging the reason/description of any
+// target that isn't yet healthy.
+func (ts *tester) describeTargetHealth() (snapshots []targetHealthSnapshot, allHealthy bool, err error) {
+	tgOut, err := ts.cfg.ELB2API.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
+		LoadBalancerArn: aws.String(ts.cfg.EKSConfig.AddOnALB2048.ALBARN),
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to describe ALB 2048 target groups (%v)", err)
+	}
+
+	if len(tgOut.TargetGroups) == 0 {
+		ts.cfg.Logger.Warn("ALB 2048 has no target groups yet", zap.String("alb-arn", ts.cfg.EKSConfig.AddOnALB2048.ALBARN))
+		return nil, false, nil
+	}
+
+	allHealthy = true
+	for _, tg := range tgOut.TargetGroups {
+		tgARN := aws.StringValue(tg.TargetGroupArn)
+		thOut, err := ts.cfg.ELB2API.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: aws.String(tgARN),
+		})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to describe target health for %q (%v)", tgARN, err)
+		}
+
+		if len(thOut.TargetHealthDescriptions) == 0 {
+			allHealthy = false
+			ts.cfg.Logger.Warn("ALB 2048 target group has no registered targets yet", zap.String("target-group-arn", tgARN))
+		}
+
+		snap := targetHealthSnapshot{TargetGroupARN: tgARN}
+		for _, desc := range thOut.TargetHealthDescriptions {
+			entry := targetHealthEntry{
+				TargetID: aws.StringValue(desc.Target.Id),
+				Port:     aws.Int64Value(desc.Target.Port),
+				State:    aws.StringValue(desc.TargetHealth.State),
+			}
+			if desc.TargetHealth.Reason != nil {
+				entry.Reason = aws.StringValue(desc.TargetHealth.Reason)
+			}
+			if desc.TargetHealth.Description != nil {
+				entry.Description = aws.StringValue(desc.TargetHealth.Description)
+			}
+			snap.Targets = append(snap.Targets, entry)
+
+			if entry.State != elbv2.TargetHealthStateEnumHealthy {
+				allHealthy = false
+				ts.cfg.Logger.Warn("ALB 2048 target not healthy yet",
+					zap.String("target-group-arn", tgARN),
+					zap.String("target-id", entry.TargetID),
+					zap.String("state", entry.State),
+					zap.String("reason", entry.Reason),
+					zap.String("description", entry.Description),
+				)
+			}
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return snapshots, allHealthy, nil
+}
@@ -0,0 +1,408 @@
+// Package csi implements tester for CSI (Container Storage Interface) drivers.
+package csi
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/exec"
+)
+
+// Config defines CSI configuration.
+type Config struct {
+	Logger    *zap.Logger
+	Stopc     chan struct{}
+	Sig       chan os.Signal
+	EKSConfig *eksconfig.Config
+	K8SClient k8sClientSetGetter
+}
+
+type k8sClientSetGetter interface {
+	KubernetesClientSet() *clientset.Clientset
+}
+
+// Tester defines CSI tester.
+type Tester interface {
+	// Create installs the configured CSI driver, provisions a StorageClass,
+	// and runs a write/read conformance suite against it.
+	Create() error
+	// Delete removes the write/read workload, the StorageClass, and the CSI driver.
+	Delete() error
+}
+
+// New creates a new CSI tester.
+func New(cfg Config) (Tester, error) {
+	return &tester{cfg: cfg}, nil
+}
+
+type tester struct {
+	cfg Config
+}
+
+// driverManifestURLs are the official static install manifests per driver,
+// used since client-go has no typed client for CSIDriver/Helm resources.
+var driverManifestURLs = map[string]string{
+	"ebs":        "https://raw.githubusercontent.com/kubernetes-sigs/aws-ebs-csi-driver/master/deploy/kubernetes/overlays/stable/ecr/base/aws-ebs-csi-driver.yaml",
+	"efs":        "https://raw.githubusercontent.com/kubernetes-sigs/aws-efs-csi-driver/master/deploy/kubernetes/base/csidriver.yaml",
+	"fsx-lustre": "https://raw.githubusercontent.com/kubernetes-sigs/aws-fsx-csi-driver/master/deploy/kubernetes/base/csidriver.yaml",
+}
+
+// provisioners maps each driver to its CSI provisioner name.
+var provisioners = map[string]string{
+	"ebs":        "ebs.csi.aws.com",
+	"efs":        "efs.csi.aws.com",
+	"fsx-lustre": "fsx.csi.aws.com",
+}
+
+func (ts *tester) Create() (err error) {
+	if ts.cfg.EKSConfig.AddOnCSI.Created {
+		ts.cfg.Logger.Info("skipping create AddOnCSI")
+		return nil
+	}
+
+	ts.cfg.EKSConfig.AddOnCSI.Created = true
+	ts.cfg.EKSConfig.Sync()
+	createStart := time.Now()
+	defer func() {
+		ts.cfg.EKSConfig.AddOnCSI.CreateTook = time.Since(createStart)
+		ts.cfg.EKSConfig.AddOnCSI.CreateTookString = ts.cfg.EKSConfig.AddOnCSI.CreateTook.String()
+		ts.cfg.EKSConfig.Sync()
+
+		if err != nil && ts.cfg.EKSConfig.OnFailureDeletePartial {
+			ts.cfg.Logger.Warn("reverting partially created AddOnCSI resources", zap.Error(err))
+			if derr := ts.Delete(); derr != nil {
+				ts.cfg.Logger.Warn("failed to revert partially created AddOnCSI resources", zap.Error(derr))
+			}
+		}
+	}()
+
+	if err = ts.installDriver(); err != nil {
+		return err
+	}
+	if err = ts.createStorageClass(); err != nil {
+		return err
+	}
+	if err = ts.createNamespace(); err != nil {
+		return err
+	}
+	if err = ts.createStatefulSet(); err != nil {
+		return err
+	}
+	if err = ts.waitStatefulSetReady(); err != nil {
+		return err
+	}
+	if err = ts.checkWritesReads(); err != nil {
+		return err
+	}
+
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.AddOnCSI.Created {
+		ts.cfg.Logger.Info("skipping delete AddOnCSI")
+		return nil
+	}
+
+	deleteStart := time.Now()
+	defer func() {
+		ts.cfg.EKSConfig.AddOnCSI.DeleteTook = time.Since(deleteStart)
+		ts.cfg.EKSConfig.AddOnCSI.DeleteTookString = ts.cfg.EKSConfig.AddOnCSI.DeleteTook.String()
+		ts.cfg.EKSConfig.Sync()
+	}()
+
+	var errs []string
+
+	if err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		StatefulSets(ts.cfg.EKSConfig.AddOnCSI.Namespace).
+		Delete(ts.cfg.EKSConfig.AddOnCSI.StatefulSetName, &metav1.DeleteOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete StatefulSet (%v)", err))
+	}
+
+	if err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Delete(ts.cfg.EKSConfig.AddOnCSI.StorageClassName, &metav1.DeleteOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete StorageClass (%v)", err))
+	}
+
+	if err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Namespaces().
+		Delete(ts.cfg.EKSConfig.AddOnCSI.Namespace, &metav1.DeleteOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Namespace (%v)", err))
+	}
+
+	if err := ts.kubectlDeleteURL(driverManifestURLs[ts.cfg.EKSConfig.AddOnCSI.Driver]); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete CSI driver manifest (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.AddOnCSI.Created = false
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) installDriver() error {
+	url, ok := driverManifestURLs[ts.cfg.EKSConfig.AddOnCSI.Driver]
+	if !ok {
+		return fmt.Errorf("unknown AddOnCSI.Driver %q", ts.cfg.EKSConfig.AddOnCSI.Driver)
+	}
+	ts.cfg.Logger.Info("installing CSI driver", zap.String("driver", ts.cfg.EKSConfig.AddOnCSI.Driver), zap.String("manifest", url))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"apply",
+		"-f",
+		url,
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' CSI driver manifest failed (output %q, error %v)", string(output), err)
+	}
+	ts.cfg.Logger.Info("installed CSI driver")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) createStorageClass() error {
+	provisioner, ok := provisioners[ts.cfg.EKSConfig.AddOnCSI.Driver]
+	if !ok {
+		return fmt.Errorf("unknown AddOnCSI.Driver %q", ts.cfg.EKSConfig.AddOnCSI.Driver)
+	}
+	ts.cfg.Logger.Info("creating StorageClass", zap.String("name", ts.cfg.EKSConfig.AddOnCSI.StorageClassName))
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Create(&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: ts.cfg.EKSConfig.AddOnCSI.StorageClassName},
+			Provisioner: provisioner,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to create StorageClass (%v)", err)
+	}
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) createNamespace() error {
+	ts.cfg.Logger.Info("creating namespace", zap.String("namespace", ts.cfg.EKSConfig.AddOnCSI.Namespace))
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Namespaces().
+		Create(&v1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: ts.cfg.EKSConfig.AddOnCSI.Namespace},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to create namespace (%v)", err)
+	}
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) createStatefulSet() error {
+	ts.cfg.Logger.Info("creating StatefulSet", zap.String("name", ts.cfg.EKSConfig.AddOnCSI.StatefulSetName))
+	replicas := int32(ts.cfg.EKSConfig.AddOnCSI.Replicas)
+	storageSize, err := resource.ParseQuantity(ts.cfg.EKSConfig.AddOnCSI.StorageSize)
+	if err != nil {
+		return fmt.Errorf("invalid AddOnCSI.StorageSize %q (%v)", ts.cfg.EKSConfig.AddOnCSI.StorageSize, err)
+	}
+
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ts.cfg.EKSConfig.AddOnCSI.StatefulSetName,
+			Namespace: ts.cfg.EKSConfig.AddOnCSI.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: ts.cfg.EKSConfig.AddOnCSI.StatefulSetName,
+			Replicas:    &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": ts.cfg.EKSConfig.AddOnCSI.StatefulSetName},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": ts.cfg.EKSConfig.AddOnCSI.StatefulSetName},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:  "writer",
+							Image: "public.ecr.aws/docker/library/busybox:latest",
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								"while true; do sleep 3600; done",
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "data", MountPath: "/data"},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "data"},
+					Spec: v1.PersistentVolumeClaimSpec{
+						AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+						StorageClassName: &ts.cfg.EKSConfig.AddOnCSI.StorageClassName,
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceStorage: storageSize},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		StatefulSets(ts.cfg.EKSConfig.AddOnCSI.Namespace).
+		Create(sts)
+	if err != nil {
+		return fmt.Errorf("failed to create StatefulSet (%v)", err)
+	}
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) waitStatefulSetReady() error {
+	ts.cfg.Logger.Info("waiting for StatefulSet to be ready")
+	ready := false
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check aborted")
+		case <-ts.cfg.Sig:
+			return errors.New("check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		sresp, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			StatefulSets(ts.cfg.EKSConfig.AddOnCSI.Namespace).
+			Get(ts.cfg.EKSConfig.AddOnCSI.StatefulSetName, metav1.GetOptions{})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get StatefulSet; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("get StatefulSet",
+			zap.Int32("ready-replicas", sresp.Status.ReadyReplicas),
+			zap.Int32("target-replicas", int32(ts.cfg.EKSConfig.AddOnCSI.Replicas)),
+		)
+		if sresp.Status.ReadyReplicas == int32(ts.cfg.EKSConfig.AddOnCSI.Replicas) {
+			ready = true
+			break
+		}
+	}
+	if !ready {
+		return errors.New("StatefulSet not ready")
+	}
+
+	ts.cfg.Logger.Info("StatefulSet is ready")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// checkWritesReads execs into each StatefulSet Pod, writes AddOnCSI.WriteSize
+// bytes to the mounted volume, reads it back, and records per-Pod latencies
+// to AddOnCSI.ResultPath.
+func (ts *tester) checkWritesReads() error {
+	ts.cfg.Logger.Info("checking writes/reads", zap.Int("write-size", ts.cfg.EKSConfig.AddOnCSI.WriteSize))
+
+	f, err := os.Create(ts.cfg.EKSConfig.AddOnCSI.ResultPath)
+	if err != nil {
+		return fmt.Errorf("failed to create ResultPath (%v)", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	defer w.Flush()
+	if err = w.Write([]string{"pod-name", "write-took-seconds", "read-took-seconds"}); err != nil {
+		return err
+	}
+
+	for i := 0; i < ts.cfg.EKSConfig.AddOnCSI.Replicas; i++ {
+		podName := fmt.Sprintf("%s-%d", ts.cfg.EKSConfig.AddOnCSI.StatefulSetName, i)
+
+		writeStart := time.Now()
+		if err = ts.kubectlExec(podName, "dd", "if=/dev/zero", "of=/data/check", fmt.Sprintf("bs=%d", ts.cfg.EKSConfig.AddOnCSI.WriteSize), "count=1"); err != nil {
+			return fmt.Errorf("write check failed for Pod %q (%v)", podName, err)
+		}
+		writeTook := time.Since(writeStart)
+
+		readStart := time.Now()
+		if err = ts.kubectlExec(podName, "dd", "if=/data/check", "of=/dev/null"); err != nil {
+			return fmt.Errorf("read check failed for Pod %q (%v)", podName, err)
+		}
+		readTook := time.Since(readStart)
+
+		ts.cfg.Logger.Info("checked Pod write/read",
+			zap.String("pod", podName),
+			zap.Duration("write-took", writeTook),
+			zap.Duration("read-took", readTook),
+		)
+		if err = w.Write([]string{podName, strconv.FormatFloat(writeTook.Seconds(), 'f', 6, 64), strconv.FormatFloat(readTook.Seconds(), 'f', 6, 64)}); err != nil {
+			return err
+		}
+	}
+
+	ts.cfg.Logger.Info("checked writes/reads", zap.String("result-path", ts.cfg.EKSConfig.AddOnCSI.ResultPath))
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) kubectlExec(podName string, cmd ...string) error {
+	args := append([]string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"exec",
+		"-n", ts.cfg.EKSConfig.AddOnCSI.Namespace,
+		podName,
+		"--",
+	}, cmd...)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl exec' failed (output %q, error %v)", string(output), err)
+	}
+	return nil
+}
+
+func (ts *tester) kubectlDeleteURL(url string) error {
+	if url == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"-f",
+		url,
+		"--ignore-not-found",
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl delete' CSI driver manifest failed (output %q, error %v)", string(output), err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package fargate
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podDiagnostics summarizes why podName may not be healthy: its Status
+// Conditions (including any "DisruptionTarget" condition EKS/Fargate sets
+// when evicting a Pod), each container's LastTerminationState, and recent
+// Events involving the Pod. The result is persisted to
+// AddOnFargate.LastPodDiagnostics so a terminal checkPod error can carry the
+// most recent reason instead of a bare timeout message. checkNode also calls
+// it on each failed retry, but returns no error when it gives up.
+func (ts *tester) podDiagnostics(podName string) string {
+	pod, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+		Get(podName, metav1.GetOptions{})
+	if err != nil {
+		diag := fmt.Sprintf("failed to get Pod %q for diagnostics (%v)", podName, err)
+		ts.cfg.Logger.Warn("pod diagnostics failed", zap.Error(err))
+		ts.cfg.EKSConfig.AddOnFargate.LastPodDiagnostics = diag
+		ts.cfg.EKSConfig.Sync()
+		return diag
+	}
+
+	var sb strings.Builder
+	for _, cond := range pod.Status.Conditions {
+		fmt.Fprintf(&sb, "condition %s=%s", cond.Type, cond.Status)
+		if cond.Reason != "" || cond.Message != "" {
+			fmt.Fprintf(&sb, " (reason=%q message=%q)", cond.Reason, cond.Message)
+		}
+		sb.WriteString("; ")
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.LastTerminationState.Terminated == nil {
+			continue
+		}
+		t := cs.LastTerminationState.Terminated
+		fmt.Fprintf(&sb, "container %s last terminated: exit=%d reason=%q message=%q; ",
+			cs.Name, t.ExitCode, t.Reason, t.Message,
+		)
+	}
+
+	events, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Events(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+		List(metav1.ListOptions{FieldSelector: "involvedObject.name=" + podName})
+	if err != nil {
+		fmt.Fprintf(&sb, "failed to list Pod events (%v); ", err)
+	} else {
+		for _, ev := range events.Items {
+			fmt.Fprintf(&sb, "event %s: %s; ", ev.Reason, ev.Message)
+		}
+	}
+
+	diag := strings.TrimSuffix(sb.String(), "; ")
+	ts.cfg.Logger.Warn("pod diagnostics", zap.String("pod-name", podName), zap.String("diagnostics", diag))
+	ts.cfg.EKSConfig.AddOnFargate.LastPodDiagnostics = diag
+	ts.cfg.EKSConfig.Sync()
+	return diag
+}
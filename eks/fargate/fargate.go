@@ -5,14 +5,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	awscfn "github.com/aws/aws-k8s-tester/pkg/aws/cloudformation"
 	awsiam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+	"github.com/aws/aws-k8s-tester/pkg/aws/wait"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
@@ -24,6 +25,7 @@ import (
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/utils/exec"
 )
@@ -45,12 +47,26 @@ type k8sClientSetGetter interface {
 	KubernetesClientSet() *clientset.Clientset
 }
 
+// Name identifies this add-on to eks/scheduler.Sequence.
+const Name = "fargate"
+
 // Tester defines Fargate tester.
 type Tester interface {
 	// Create creates Fargate pods.
 	Create() error
 	// Delete deletes Fargate pods.
 	Delete() error
+	// Dependencies returns the names of add-ons that must finish Create
+	// before this one starts, so eks/scheduler.Sequence can topologically
+	// order concurrent add-on installs. Fargate depends on nothing else.
+	Dependencies() []string
+	// IsProfileActive reports whether every configured Fargate profile has
+	// reached ACTIVE. When EKSConfig.FargateFirst is true, a deployer must
+	// poll this to true, and CoreDNS must already be rescheduled off EC2 via
+	// Create's scheduleCoreDNSOnFargate step, before it starts creating node
+	// groups -- otherwise CoreDNS can be scheduled onto an EC2 node that is
+	// about to be replaced by the node group rollout.
+	IsProfileActive() (bool, error)
 }
 
 // New creates a new Job tester.
@@ -60,9 +76,14 @@ func New(cfg Config) (Tester, error) {
 
 type tester struct {
 	cfg Config
+
+	// profileMu serializes CreateFargateProfile/DeleteFargateProfile calls
+	// for this cluster, since EKS only allows one Fargate profile per
+	// cluster to be CREATING or DELETING at a time.
+	profileMu sync.Mutex
 }
 
-func (ts *tester) Create() error {
+func (ts *tester) Create() (err error) {
 	if ts.cfg.EKSConfig.AddOnFargate.Created {
 		ts.cfg.Logger.Info("skipping create AddOnFargate")
 		return nil
@@ -76,27 +97,40 @@ func (ts *tester) Create() error {
 		ts.cfg.EKSConfig.AddOnFargate.CreateTook = time.Since(createStart)
 		ts.cfg.EKSConfig.AddOnFargate.CreateTookString = ts.cfg.EKSConfig.AddOnFargate.CreateTook.String()
 		ts.cfg.EKSConfig.Sync()
+
+		if err != nil && ts.cfg.EKSConfig.OnFailureDeletePartial {
+			ts.cfg.Logger.Warn("reverting partially created AddOnFargate resources", zap.Error(err))
+			if derr := ts.Delete(); derr != nil {
+				ts.cfg.Logger.Warn("failed to revert partially created AddOnFargate resources", zap.Error(derr))
+			}
+		}
 	}()
 
-	if err := ts.createNamespace(); err != nil {
+	if err = ts.createNamespace(); err != nil {
+		return err
+	}
+	if err = ts.createRole(); err != nil {
+		return err
+	}
+	if err = ts.createSecret(); err != nil {
 		return err
 	}
-	if err := ts.createRole(); err != nil {
+	if err = ts.createProfile(); err != nil {
 		return err
 	}
-	if err := ts.createSecret(); err != nil {
+	if err = ts.scheduleCoreDNSOnFargate(); err != nil {
 		return err
 	}
-	if err := ts.createProfile(); err != nil {
+	if err = ts.createIRSARole(); err != nil {
 		return err
 	}
-	if err := ts.createPod(); err != nil {
+	if err = ts.createPod(); err != nil {
 		return err
 	}
-	if err := ts.checkPod(); err != nil {
+	if err = ts.checkPod(); err != nil {
 		return err
 	}
-	if err := ts.checkNode(); err != nil {
+	if err = ts.checkNode(); err != nil {
 		return err
 	}
 
@@ -123,6 +157,14 @@ func (ts *tester) Delete() error {
 	}
 	ts.cfg.Logger.Info("wait after deleting Fargate Pod")
 
+	if err := ts.deleteIRSARole(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Fargate IRSA role (%v)", err))
+	}
+
+	if err := ts.unscheduleCoreDNSOnFargate(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to unschedule CoreDNS from Fargate (%v)", err))
+	}
+
 	if err := ts.deleteProfile(); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to delete Fargate profile (%v)", err))
 	}
@@ -146,6 +188,37 @@ func (ts *tester) Delete() error {
 	return ts.cfg.EKSConfig.Sync()
 }
 
+// Dependencies reports that Fargate depends on no other add-on; it is a
+// root in the add-on dependency graph.
+func (ts *tester) Dependencies() []string {
+	return nil
+}
+
+// IsProfileActive reports whether the default Fargate profile and every
+// profile in AddOnFargate.Profiles are ACTIVE, so a scheduler can gate
+// dependent add-ons (e.g. nodegroups whose CFN stacks must not start while
+// the cluster still has a profile CREATING) on Fargate readiness.
+func (ts *tester) IsProfileActive() (bool, error) {
+	names := []string{ts.cfg.EKSConfig.AddOnFargate.ProfileName}
+	for _, spec := range ts.cfg.EKSConfig.AddOnFargate.Profiles {
+		names = append(names, spec.Name)
+	}
+
+	for _, name := range names {
+		out, err := ts.cfg.EKSAPI.DescribeFargateProfile(&eks.DescribeFargateProfileInput{
+			ClusterName:        aws.String(ts.cfg.EKSConfig.Name),
+			FargateProfileName: aws.String(name),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to describe Fargate profile %q (%v)", name, err)
+		}
+		if out.FargateProfile == nil || aws.StringValue(out.FargateProfile.Status) != eks.FargateProfileStatusActive {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (ts *tester) createNamespace() error {
 	ts.cfg.Logger.Info("creating namespace", zap.String("namespace", ts.cfg.EKSConfig.AddOnFargate.Namespace))
 	_, err := ts.cfg.K8SClient.KubernetesClientSet().
@@ -243,13 +316,14 @@ Outputs:
 func (ts *tester) createRole() error {
 	if !ts.cfg.EKSConfig.AddOnFargate.RoleCreate {
 		ts.cfg.Logger.Info("EKSConfig.AddOnFargate.RoleCreate false; skipping creation")
+		partition := eksconfig.GetPartition(ts.cfg.EKSConfig.Region)
 		return awsiam.Validate(
 			ts.cfg.Logger,
 			ts.cfg.IAMAPI,
 			ts.cfg.EKSConfig.AddOnFargate.RoleName,
 			[]string{
-				"eks.amazonaws.com",
-				"eks-fargate-pods.amazonaws.com",
+				eksconfig.RequiredServicePrincipal("eks.amazonaws.com", partition),
+				eksconfig.RequiredServicePrincipal("eks-fargate-pods.amazonaws.com", partition),
 			},
 			[]string{
 				"arn:aws:iam::aws:policy/AmazonEKSFargatePodExecutionRolePolicy",
@@ -448,26 +522,35 @@ func (ts *tester) createProfile() error {
 	}
 	ts.cfg.Logger.Info("creating fargate profile", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.ProfileName))
 
-	req, _ := ts.cfg.EKSAPI.CreateFargateProfileRequest(&eks.CreateFargateProfileInput{
+	selectors := []*eks.FargateProfileSelector{
+		{
+			Namespace: aws.String(ts.cfg.EKSConfig.AddOnFargate.Namespace),
+		},
+	}
+	if ts.shouldScheduleCoreDNSOnFargate() {
+		ts.cfg.Logger.Info("adding fargate profile selector for kube-system/coredns")
+		selectors = append(selectors, &eks.FargateProfileSelector{
+			Namespace: aws.String(coreDNSNamespace),
+			Labels:    aws.StringMap(map[string]string{"k8s-app": "kube-dns"}),
+		})
+	}
+
+	ts.profileMu.Lock()
+	err := ts.sendCreateFargateProfile(&eks.CreateFargateProfileInput{
 		ClusterName:         aws.String(ts.cfg.EKSConfig.Name),
 		FargateProfileName:  aws.String(ts.cfg.EKSConfig.AddOnFargate.ProfileName),
 		PodExecutionRoleArn: aws.String(ts.cfg.EKSConfig.AddOnFargate.RoleARN),
 		Subnets:             aws.StringSlice(ts.cfg.EKSConfig.Parameters.PrivateSubnetIDs),
-		Selectors: []*eks.FargateProfileSelector{
-			{
-				Namespace: aws.String(ts.cfg.EKSConfig.AddOnFargate.Namespace),
-			},
-		},
-	})
-	err := req.Send()
+		Selectors:           selectors,
+	}, true)
 	if err != nil {
+		ts.profileMu.Unlock()
 		return err
 	}
 	ts.cfg.Logger.Info("sent create fargate profile request")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	ch := Poll(
-		ctx,
+	pollErr := Poll(
+		context.Background(),
 		ts.cfg.Stopc,
 		ts.cfg.Logger,
 		ts.cfg.EKSAPI,
@@ -476,22 +559,145 @@ func (ts *tester) createProfile() error {
 		eks.FargateProfileStatusActive,
 		10*time.Second,
 		7*time.Second,
+		5*time.Minute,
+		nil,
 	)
-	for sv := range ch {
-		if sv.Error != nil {
-			cancel()
-			return sv.Error
-		}
+	ts.profileMu.Unlock()
+	if pollErr != nil {
+		return pollErr
 	}
-	cancel()
 
 	ts.cfg.Logger.Info("created fargate profile", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.ProfileName))
+	if err := ts.cfg.EKSConfig.Sync(); err != nil {
+		return err
+	}
+
+	for _, spec := range ts.cfg.EKSConfig.AddOnFargate.Profiles {
+		if err := ts.createProfileSpec(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createProfileSpec creates a single additional Fargate profile from spec,
+// reusing AddOnFargate.RoleARN as its pod execution role. EKS only allows
+// one Fargate profile to be CREATING per cluster at a time, so this waits
+// for ACTIVE before returning, throttling the createProfile loop above.
+func (ts *tester) createProfileSpec(spec eksconfig.FargateProfileSpec) error {
+	subnets := spec.Subnets
+	if len(subnets) == 0 {
+		subnets = ts.cfg.EKSConfig.Parameters.PrivateSubnetIDs
+	}
+	if len(subnets) == 0 {
+		return fmt.Errorf("empty subnets for fargate profile %q", spec.Name)
+	}
+
+	selectors := make([]*eks.FargateProfileSelector, 0, len(spec.Selectors))
+	for _, sel := range spec.Selectors {
+		s := &eks.FargateProfileSelector{Namespace: aws.String(sel.Namespace)}
+		if len(sel.Labels) > 0 {
+			s.Labels = aws.StringMap(sel.Labels)
+		}
+		selectors = append(selectors, s)
+	}
+
+	ts.cfg.Logger.Info("creating additional fargate profile", zap.String("name", spec.Name))
+
+	ts.profileMu.Lock()
+	defer ts.profileMu.Unlock()
+
+	// the default profile already absorbed the IAM eventual-consistency
+	// retry in createProfile; by the time we get here the role is visible.
+	if err := ts.sendCreateFargateProfile(&eks.CreateFargateProfileInput{
+		ClusterName:         aws.String(ts.cfg.EKSConfig.Name),
+		FargateProfileName:  aws.String(spec.Name),
+		PodExecutionRoleArn: aws.String(ts.cfg.EKSConfig.AddOnFargate.RoleARN),
+		Subnets:             aws.StringSlice(subnets),
+		Selectors:           selectors,
+	}, false); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("sent create fargate profile request", zap.String("name", spec.Name))
+
+	if err := Poll(
+		context.Background(),
+		ts.cfg.Stopc,
+		ts.cfg.Logger,
+		ts.cfg.EKSAPI,
+		ts.cfg.EKSConfig.Name,
+		spec.Name,
+		eks.FargateProfileStatusActive,
+		10*time.Second,
+		7*time.Second,
+		5*time.Minute,
+		nil,
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created additional fargate profile", zap.String("name", spec.Name))
 	return ts.cfg.EKSConfig.Sync()
 }
 
+// CreateProfiles creates each of specs via createProfileSpec, serialized by
+// profileMu so concurrent callers queue behind EKS's one-profile-CREATING-
+// at-a-time limit instead of racing into ResourceInUseException. Unlike
+// createProfile/createProfileSpec's internal use, it does not stop at the
+// first failure: the returned slice has one entry per spec, in order, with
+// a nil entry for any spec that succeeded.
+func (ts *tester) CreateProfiles(specs []eksconfig.FargateProfileSpec) []error {
+	results := make([]error, len(specs))
+	for i, spec := range specs {
+		results[i] = ts.createProfileSpec(spec)
+	}
+	return results
+}
+
+// sendCreateFargateProfile issues CreateFargateProfileRequest, and when
+// retryIAMConsistency is true, retries for up to a minute on the
+// InvalidParameterException EKS returns while a just-created pod execution
+// role hasn't yet propagated through IAM. Only the first profile in a batch
+// needs this: once it succeeds, the role is known visible to EKS.
+func (ts *tester) sendCreateFargateProfile(input *eks.CreateFargateProfileInput, retryIAMConsistency bool) error {
+	req, _ := ts.cfg.EKSAPI.CreateFargateProfileRequest(input)
+	if !retryIAMConsistency {
+		return req.Send()
+	}
+
+	var err error
+	retryStart := time.Now()
+	for time.Now().Sub(retryStart) < time.Minute {
+		req, _ = ts.cfg.EKSAPI.CreateFargateProfileRequest(input)
+		err = req.Send()
+		if err == nil {
+			return nil
+		}
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != eks.ErrCodeInvalidParameterException {
+			return err
+		}
+		ts.cfg.Logger.Warn("pod execution role not yet visible to EKS; retrying create fargate profile", zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return err
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return err
+}
+
 func (ts *tester) deleteProfile() error {
+	for _, spec := range ts.cfg.EKSConfig.AddOnFargate.Profiles {
+		if err := ts.deleteProfileSpec(spec.Name); err != nil {
+			return err
+		}
+	}
+
 	ts.cfg.Logger.Info("deleting fargate profile", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.ProfileName))
 
+	ts.profileMu.Lock()
+	defer ts.profileMu.Unlock()
+
 	var err error
 	retryStart := time.Now()
 	for time.Now().Sub(retryStart) < time.Minute {
@@ -517,7 +723,7 @@ func (ts *tester) deleteProfile() error {
 		break
 	}
 
-	ch := Poll(
+	if err := Poll(
 		context.Background(),
 		ts.cfg.Stopc,
 		ts.cfg.Logger,
@@ -527,17 +733,73 @@ func (ts *tester) deleteProfile() error {
 		FargateProfileStatusDELETEDORNOTEXIST,
 		10*time.Second,
 		7*time.Second,
-	)
-	for sv := range ch {
-		if sv.Error != nil {
-			return sv.Error
-		}
+		5*time.Minute,
+		nil,
+	); err != nil {
+		return err
 	}
 
 	ts.cfg.Logger.Info("deleted fargate profile", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.ProfileName))
 	return ts.cfg.EKSConfig.Sync()
 }
 
+// deleteProfileSpec issues DeleteFargateProfile for an additional profile by
+// name, the counterpart to createProfileSpec, and waits for it to reach
+// FargateProfileStatusDELETEDORNOTEXIST before returning. Deletes are
+// serialized through profileMu just like creates: EKS only allows one
+// Fargate profile per cluster to be CREATING or DELETING at a time.
+func (ts *tester) deleteProfileSpec(name string) error {
+	ts.cfg.Logger.Info("deleting additional fargate profile", zap.String("name", name))
+
+	ts.profileMu.Lock()
+	defer ts.profileMu.Unlock()
+
+	var err error
+	retryStart := time.Now()
+	for time.Now().Sub(retryStart) < time.Minute {
+		_, err = ts.cfg.EKSAPI.DeleteFargateProfile(&eks.DeleteFargateProfileInput{
+			ClusterName:        aws.String(ts.cfg.EKSConfig.Name),
+			FargateProfileName: aws.String(name),
+		})
+		if err != nil {
+			if IsProfileDeleted(err) {
+				err = nil
+				break
+			}
+			ts.cfg.Logger.Warn("failed to delete fargate profile; retrying", zap.String("name", name), zap.Error(err))
+			select {
+			case <-ts.cfg.Stopc:
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		break
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := Poll(
+		context.Background(),
+		ts.cfg.Stopc,
+		ts.cfg.Logger,
+		ts.cfg.EKSAPI,
+		ts.cfg.EKSConfig.Name,
+		name,
+		FargateProfileStatusDELETEDORNOTEXIST,
+		10*time.Second,
+		7*time.Second,
+		5*time.Minute,
+		nil,
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted additional fargate profile", zap.String("name", name))
+	return ts.cfg.EKSConfig.Sync()
+}
+
 func (ts *tester) createPod() error {
 	if err := ts.listPods(ts.cfg.EKSConfig.AddOnFargate.Namespace); err != nil {
 		ts.cfg.Logger.Warn("listing pods failed", zap.Error(err))
@@ -545,6 +807,48 @@ func (ts *tester) createPod() error {
 
 	ts.cfg.Logger.Info("creating Pod")
 
+	irsaEnabled := ts.cfg.EKSConfig.AddOnFargate.IRSA != nil && ts.cfg.EKSConfig.AddOnFargate.IRSA.Enable
+
+	containers := []v1.Container{
+		{
+			Name:            ts.cfg.EKSConfig.AddOnFargate.ContainerName,
+			Image:           "amazonlinux",
+			ImagePullPolicy: v1.PullIfNotPresent,
+			Command: []string{
+				"/bin/sh",
+				"-c",
+			},
+			Args: []string{
+				fmt.Sprintf("cat /tmp/%s && sleep 10000", ts.cfg.EKSConfig.AddOnFargate.SecretName),
+			},
+
+			// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
+			VolumeMounts: []v1.VolumeMount{
+				{
+					Name:      "secret-volume",
+					MountPath: "/tmp",
+					ReadOnly:  true,
+				},
+			},
+		},
+	}
+	if irsaEnabled {
+		// proves the ServiceAccount's projected token is exchanged for the
+		// IRSA role, rather than the Pod inheriting the node execution role.
+		containers = append(containers, v1.Container{
+			Name:            fargateIRSAContainerName,
+			Image:           "amazon/aws-cli",
+			ImagePullPolicy: v1.PullIfNotPresent,
+			Command: []string{
+				"/bin/sh",
+				"-c",
+			},
+			Args: []string{
+				"aws sts get-caller-identity && sleep 10000",
+			},
+		})
+	}
+
 	pod := &v1.Pod{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -556,29 +860,7 @@ func (ts *tester) createPod() error {
 		},
 		Spec: v1.PodSpec{
 			RestartPolicy: v1.RestartPolicyOnFailure,
-			Containers: []v1.Container{
-				{
-					Name:            ts.cfg.EKSConfig.AddOnFargate.ContainerName,
-					Image:           "amazonlinux",
-					ImagePullPolicy: v1.PullIfNotPresent,
-					Command: []string{
-						"/bin/sh",
-						"-c",
-					},
-					Args: []string{
-						fmt.Sprintf("cat /tmp/%s && sleep 10000", ts.cfg.EKSConfig.AddOnFargate.SecretName),
-					},
-
-					// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
-					VolumeMounts: []v1.VolumeMount{
-						{
-							Name:      "secret-volume",
-							MountPath: "/tmp",
-							ReadOnly:  true,
-						},
-					},
-				},
-			},
+			Containers:    containers,
 
 			// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
 			Volumes: []v1.Volume{
@@ -593,6 +875,9 @@ func (ts *tester) createPod() error {
 			},
 		},
 	}
+	if irsaEnabled {
+		pod.Spec.ServiceAccountName = ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName
+	}
 	_, err := ts.cfg.K8SClient.KubernetesClientSet().
 		CoreV1().
 		Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
@@ -692,8 +977,9 @@ func (ts *tester) checkPod() error {
 	}
 
 	if !found {
+		diag := ts.podDiagnostics(ts.cfg.EKSConfig.AddOnFargate.PodName)
 		ts.cfg.EKSConfig.Sync()
-		return errors.New("failed to check Pod")
+		return fmt.Errorf("failed to check Pod (%s)", diag)
 	}
 
 	// TODO: not working...
@@ -742,6 +1028,12 @@ func (ts *tester) checkPod() error {
 		}
 	*/
 
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA != nil && ts.cfg.EKSConfig.AddOnFargate.IRSA.Enable {
+		if err := ts.checkIRSA(); err != nil {
+			return err
+		}
+	}
+
 	return ts.cfg.EKSConfig.Sync()
 }
 
@@ -789,12 +1081,144 @@ func (ts *tester) checkNode() error {
 		if readies >= desired {
 			break
 		}
+		ts.podDiagnostics(ts.cfg.EKSConfig.AddOnFargate.PodName)
 	}
 
 	ts.cfg.Logger.Info("checked node")
 	return ts.cfg.EKSConfig.Sync()
 }
 
+const (
+	coreDNSNamespace      = "kube-system"
+	coreDNSDeploymentName = "coredns"
+	coreDNSComputeTypeKey = "eks.amazonaws.com/compute-type"
+)
+
+// shouldScheduleCoreDNSOnFargate reports whether kube-system/coredns should
+// get its own FargateProfileSelector: the user opted in, and there's no
+// EC2 nodegroup around for CoreDNS to keep running on instead.
+func (ts *tester) shouldScheduleCoreDNSOnFargate() bool {
+	if !ts.cfg.EKSConfig.AddOnFargate.ScheduleCoreDNSOnFargate {
+		return false
+	}
+	return !ts.cfg.EKSConfig.IsEnabledAddOnNodeGroups() && !ts.cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups()
+}
+
+// scheduleCoreDNSOnFargate patches the coredns Deployment to drop the
+// "eks.amazonaws.com/compute-type: ec2" annotation that pins it to EC2,
+// rolls the Deployment out, and waits for its pods to land on fargate-*
+// nodes. A no-op unless shouldScheduleCoreDNSOnFargate is true.
+func (ts *tester) scheduleCoreDNSOnFargate() error {
+	if !ts.shouldScheduleCoreDNSOnFargate() {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("patching CoreDNS Deployment to remove EC2 compute-type annotation")
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:null}}}}}`, coreDNSComputeTypeKey)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(coreDNSNamespace).
+		Patch(coreDNSDeploymentName, types.StrategicMergePatchType, []byte(patch))
+	if err != nil {
+		return fmt.Errorf("failed to patch CoreDNS Deployment (%v)", err)
+	}
+
+	if err := ts.rolloutRestartCoreDNS(); err != nil {
+		return err
+	}
+	return ts.waitCoreDNSOnFargate()
+}
+
+// unscheduleCoreDNSOnFargate reverses scheduleCoreDNSOnFargate by restoring
+// the "eks.amazonaws.com/compute-type: ec2" annotation, so CoreDNS falls
+// back onto EC2 once the Fargate profile that hosted it is torn down.
+func (ts *tester) unscheduleCoreDNSOnFargate() error {
+	if !ts.cfg.EKSConfig.AddOnFargate.ScheduleCoreDNSOnFargate {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("restoring CoreDNS Deployment EC2 compute-type annotation")
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:"ec2"}}}}}`, coreDNSComputeTypeKey)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(coreDNSNamespace).
+		Patch(coreDNSDeploymentName, types.StrategicMergePatchType, []byte(patch))
+	if err != nil {
+		return fmt.Errorf("failed to restore CoreDNS Deployment annotation (%v)", err)
+	}
+	return ts.rolloutRestartCoreDNS()
+}
+
+func (ts *tester) rolloutRestartCoreDNS() error {
+	cmdFlags := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + coreDNSNamespace,
+		"rollout",
+		"restart",
+		"deployment/" + coreDNSDeploymentName,
+	}
+	ts.cfg.Logger.Info("rolling out CoreDNS Deployment",
+		zap.String("command", ts.cfg.EKSConfig.KubectlPath+" "+strings.Join(cmdFlags, " ")),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, cmdFlags...).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl rollout restart' failed (output %q, error %v)", string(output), err)
+	}
+	return nil
+}
+
+// waitCoreDNSOnFargate waits for every kube-system/k8s-app=kube-dns pod to
+// become Ready on a "fargate-*" node.
+func (ts *tester) waitCoreDNSOnFargate() error {
+	ts.cfg.Logger.Info("waiting for CoreDNS pods to become Ready on Fargate")
+	retryStart, waitDur := time.Now(), 3*time.Minute
+	for time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("aborted waiting for CoreDNS on Fargate")
+		case <-time.After(10 * time.Second):
+		}
+
+		pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(coreDNSNamespace).
+			List(metav1.ListOptions{LabelSelector: "k8s-app=kube-dns"})
+		if err != nil {
+			ts.cfg.Logger.Warn("listing CoreDNS pods failed", zap.Error(err))
+			continue
+		}
+		if len(pods.Items) == 0 {
+			continue
+		}
+
+		ready := true
+		for _, pod := range pods.Items {
+			if !strings.HasPrefix(pod.Spec.NodeName, "fargate-") {
+				ready = false
+				break
+			}
+			podReady := false
+			for _, cond := range pod.Status.Conditions {
+				if cond.Type == v1.PodReady && cond.Status == v1.ConditionTrue {
+					podReady = true
+					break
+				}
+			}
+			if !podReady {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			ts.cfg.Logger.Info("CoreDNS pods are Ready on Fargate", zap.Int("pods", len(pods.Items)))
+			return nil
+		}
+	}
+	return errors.New("timed out waiting for CoreDNS pods to become Ready on Fargate")
+}
+
 // FargateProfileStatusDELETEDORNOTEXIST defines the cluster status when the cluster is not found.
 //
 // ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_FargateProfile.html
@@ -813,8 +1237,10 @@ type FargateProfileStatus struct {
 	Error          error
 }
 
-// Poll periodically fetches the fargate profile status
-// until the node group becomes the desired state.
+// Poll periodically fetches the Fargate profile status, calling onUpdate
+// (if non-nil) with each observation, until profileName reaches
+// desiredStatus, reports a terminal CREATE_FAILED/DELETE_FAILED status,
+// or ctx/stopc/timeout expires; it returns the terminal error, if any.
 func Poll(
 	ctx context.Context,
 	stopc chan struct{},
@@ -824,127 +1250,108 @@ func Poll(
 	profileName string,
 	desiredStatus string,
 	initialWait time.Duration,
-	wait time.Duration,
-) <-chan FargateProfileStatus {
+	interval time.Duration,
+	timeout time.Duration,
+	onUpdate func(FargateProfileStatus),
+) error {
 	lg.Info("polling fargate profile",
 		zap.String("cluster-name", clusterName),
 		zap.String("profile-name", profileName),
 		zap.String("desired-fargate-status", desiredStatus),
 	)
-
 	now := time.Now()
 
-	ch := make(chan FargateProfileStatus, 10)
-	go func() {
-		// very first poll should be no-wait
-		// in case stack has already reached desired status
-		// wait from second interation
-		waitDur := time.Duration(0)
-
-		first := true
-		for ctx.Err() == nil {
-			select {
-			case <-ctx.Done():
-				lg.Warn("wait aborted", zap.Error(ctx.Err()))
-				ch <- FargateProfileStatus{FargateProfile: nil, Error: ctx.Err()}
-				close(ch)
-				return
-
-			case <-stopc:
-				lg.Warn("wait stopped", zap.Error(ctx.Err()))
-				ch <- FargateProfileStatus{FargateProfile: nil, Error: errors.New("wait stopped")}
-				close(ch)
-				return
-
-			case <-time.After(waitDur):
-				// very first poll should be no-wait
-				// in case stack has already reached desired status
-				// wait from second interation
-				if waitDur == time.Duration(0) {
-					waitDur = wait
+	return wait.PollImmediateUntil(ctx, stopc, initialWait, interval, timeout, func() (bool, error) {
+		output, err := eksAPI.DescribeFargateProfile(&eks.DescribeFargateProfileInput{
+			ClusterName:        aws.String(clusterName),
+			FargateProfileName: aws.String(profileName),
+		})
+		if err != nil {
+			if IsProfileDeleted(err) {
+				if desiredStatus == FargateProfileStatusDELETEDORNOTEXIST {
+					lg.Info("fargate profile is already deleted as desired; exiting", zap.Error(err))
+					return true, nil
 				}
-			}
-
-			output, err := eksAPI.DescribeFargateProfile(&eks.DescribeFargateProfileInput{
-				ClusterName:        aws.String(clusterName),
-				FargateProfileName: aws.String(profileName),
-			})
-			if err != nil {
-				if IsProfileDeleted(err) {
-					if desiredStatus == FargateProfileStatusDELETEDORNOTEXIST {
-						lg.Info("fargate profile is already deleted as desired; exiting", zap.Error(err))
-						ch <- FargateProfileStatus{FargateProfile: nil, Error: nil}
-						close(ch)
-						return
-					}
-
-					lg.Warn("fargate profile does not exist", zap.Error(err))
-					lg.Warn("aborting", zap.Error(ctx.Err()))
-					ch <- FargateProfileStatus{FargateProfile: nil, Error: err}
-					close(ch)
-					return
+				lg.Warn("fargate profile does not exist", zap.Error(err))
+				if onUpdate != nil {
+					onUpdate(FargateProfileStatus{Error: err})
 				}
-
-				lg.Warn("describe fargate profile failed; retrying", zap.Error(err))
-				ch <- FargateProfileStatus{FargateProfile: nil, Error: err}
-				continue
+				return false, wait.Terminal(err)
 			}
 
-			if output.FargateProfile == nil {
-				lg.Warn("expected non-nil fargate profile; retrying")
-				ch <- FargateProfileStatus{FargateProfile: nil, Error: fmt.Errorf("unexpected empty response %+v", output.GoString())}
-				continue
+			lg.Warn("describe fargate profile failed; retrying", zap.Error(err))
+			if onUpdate != nil {
+				onUpdate(FargateProfileStatus{Error: err})
 			}
+			return false, nil
+		}
 
-			fargateProfile := output.FargateProfile
-			currentStatus := aws.StringValue(fargateProfile.Status)
-			lg.Info("poll",
-				zap.String("cluster-name", clusterName),
-				zap.String("fargate-name", profileName),
-				zap.String("fargate-status", currentStatus),
-				zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
-			)
-			switch currentStatus {
-			case desiredStatus:
-				ch <- FargateProfileStatus{FargateProfile: fargateProfile, Error: nil}
-				lg.Info("became desired fargate profile status; exiting", zap.String("status", currentStatus))
-				close(ch)
-				return
-
-			case eks.FargateProfileStatusCreateFailed,
-				eks.FargateProfileStatusDeleteFailed:
-				ch <- FargateProfileStatus{FargateProfile: fargateProfile, Error: fmt.Errorf("unexpected fargate status %q", currentStatus)}
-				close(ch)
-				return
-			default:
-				ch <- FargateProfileStatus{FargateProfile: fargateProfile, Error: nil}
-			}
+		if output.FargateProfile == nil {
+			lg.Warn("expected non-nil fargate profile; retrying")
+			return false, nil
+		}
 
-			if first {
-				lg.Info("sleeping", zap.Duration("initial-wait", initialWait))
-				select {
-				case <-ctx.Done():
-					lg.Warn("wait aborted", zap.Error(ctx.Err()))
-					ch <- FargateProfileStatus{FargateProfile: nil, Error: ctx.Err()}
-					close(ch)
-					return
-				case <-stopc:
-					lg.Warn("wait stopped", zap.Error(ctx.Err()))
-					ch <- FargateProfileStatus{FargateProfile: nil, Error: errors.New("wait stopped")}
-					close(ch)
-					return
-				case <-time.After(initialWait):
-				}
-				first = false
-			}
+		fargateProfile := output.FargateProfile
+		currentStatus := aws.StringValue(fargateProfile.Status)
+		lg.Info("poll",
+			zap.String("cluster-name", clusterName),
+			zap.String("fargate-name", profileName),
+			zap.String("fargate-status", currentStatus),
+			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+		)
+		if onUpdate != nil {
+			onUpdate(FargateProfileStatus{FargateProfile: fargateProfile})
 		}
 
-		lg.Warn("wait aborted", zap.Error(ctx.Err()))
-		ch <- FargateProfileStatus{FargateProfile: nil, Error: ctx.Err()}
-		close(ch)
-		return
-	}()
-	return ch
+		switch currentStatus {
+		case desiredStatus:
+			lg.Info("became desired fargate profile status; exiting", zap.String("status", currentStatus))
+			return true, nil
+		case eks.FargateProfileStatusCreateFailed, eks.FargateProfileStatusDeleteFailed:
+			return false, wait.Terminal(fmt.Errorf("unexpected fargate status %q", currentStatus))
+		default:
+			return false, nil
+		}
+	})
+}
+
+// PollAll fans Poll out across multiple profiles concurrently and waits for
+// every one of them to report desiredStatus, returning the combined error
+// if any profile failed. Use this for status checks that don't share
+// EKS's one-profile-CREATING-at-a-time restriction on creates.
+func PollAll(
+	ctx context.Context,
+	stopc chan struct{},
+	lg *zap.Logger,
+	eksAPI eksiface.EKSAPI,
+	clusterName string,
+	profileNames []string,
+	desiredStatus string,
+	initialWait time.Duration,
+	interval time.Duration,
+) error {
+	var wg sync.WaitGroup
+	errc := make(chan error, len(profileNames))
+	for _, name := range profileNames {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := Poll(ctx, stopc, lg, eksAPI, clusterName, name, desiredStatus, initialWait, interval, 15*time.Minute, nil); err != nil {
+				errc <- err
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(errc)
+
+	var errs []string
+	for err := range errc {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
 }
 
 // IsProfileDeleted returns true if error from EKS API indicates that
@@ -961,13 +1368,3 @@ func IsProfileDeleted(err error) bool {
 	return strings.Contains(err.Error(), " not found ")
 }
 
-const ll = "0123456789abcdefghijklmnopqrstuvwxyz"
-
-func randString(n int) string {
-	b := make([]byte, n)
-	for i := range b {
-		rand.Seed(time.Now().UnixNano())
-		b[i] = ll[rand.Intn(len(ll))]
-	}
-	return string(b)
-}
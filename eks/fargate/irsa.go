@@ -0,0 +1,400 @@
+package fargate
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	awscfn "github.com/aws/aws-k8s-tester/pkg/aws/cloudformation"
+	"github.com/aws/aws-k8s-tester/version"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+// fargateIRSAContainerName is the container that exercises the IRSA role by
+// calling "aws sts get-caller-identity" from inside the Fargate test Pod.
+const fargateIRSAContainerName = "irsa-check"
+
+// TemplateFargateIRSARole is the CloudFormation template for the IAM role
+// the Fargate test Pod's ServiceAccount assumes via IRSA. Unlike
+// AddOnFargate's pod execution role, this role is never attached to the
+// Fargate profile itself -- it is only ever assumed by the ServiceAccount
+// through the cluster's OIDC identity provider.
+// ref. https://aws.amazon.com/blogs/opensource/introducing-fine-grained-iam-roles-service-accounts/
+const TemplateFargateIRSARole = `
+---
+AWSTemplateFormatVersion: '2010-09-09'
+Description: 'IAM role for the Fargate test Pod ServiceAccount (IRSA)'
+
+Parameters:
+
+  ClusterOIDCProviderARN:
+    Type: String
+    Description: The ARN of the cluster's IAM OIDC identity provider.
+
+  ClusterOIDCProviderURL:
+    Type: String
+    Description: The OIDC issuer URL of the cluster, without the leading "https://".
+
+  ServiceAccountNamespace:
+    Type: String
+    Description: The Namespace of the Fargate test Pod ServiceAccount.
+
+  ServiceAccountName:
+    Type: String
+    Description: The Name of the Fargate test Pod ServiceAccount.
+
+Resources:
+
+  FargateIRSARole:
+    Type: AWS::IAM::Role
+    Properties:
+      AssumeRolePolicyDocument:
+        Version: '2012-10-17'
+        Statement:
+        - Effect: Allow
+          Principal:
+            Federated: !Ref ClusterOIDCProviderARN
+          Action: sts:AssumeRoleWithWebIdentity
+          Condition:
+            StringEquals:
+              !Sub '${ClusterOIDCProviderURL}:sub': !Sub 'system:serviceaccount:${ServiceAccountNamespace}:${ServiceAccountName}'
+
+Outputs:
+
+  FargateIRSARoleARN:
+    Value: !GetAtt FargateIRSARole.Arn
+    Description: The IAM role ARN assumed by the Fargate test Pod ServiceAccount
+
+`
+
+// ensureOIDCProvider associates the cluster's OIDC issuer with IAM as an
+// OpenID Connect identity provider if one isn't already associated, so IRSA
+// roles can trust it. It is idempotent -- most clusters will already have
+// this associated outside of AddOnFargate, in which case this is a no-op.
+func (ts *tester) ensureOIDCProvider() error {
+	if ts.cfg.EKSConfig.Status.ClusterOIDCProviderARN != "" {
+		ts.cfg.Logger.Info("cluster OIDC provider already associated",
+			zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCProviderARN),
+		)
+		return nil
+	}
+	if ts.cfg.EKSConfig.Status.ClusterOIDCProviderURL == "" {
+		return errors.New("cannot associate cluster OIDC provider with an empty Status.ClusterOIDCProviderURL")
+	}
+
+	issuerURL := "https://" + ts.cfg.EKSConfig.Status.ClusterOIDCProviderURL
+	thumbprint, err := oidcIssuerThumbprint(issuerURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch OIDC issuer TLS thumbprint (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("associating cluster OIDC provider with IAM", zap.String("issuer-url", issuerURL))
+	out, err := ts.cfg.IAMAPI.CreateOpenIDConnectProvider(&iam.CreateOpenIDConnectProviderInput{
+		Url:            aws.String(issuerURL),
+		ClientIDList:   aws.StringSlice([]string{"sts.amazonaws.com"}),
+		ThumbprintList: aws.StringSlice([]string{thumbprint}),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeEntityAlreadyExistsException {
+			ts.cfg.Logger.Info("cluster OIDC provider already associated with IAM")
+		} else {
+			return fmt.Errorf("failed to associate cluster OIDC provider (%v)", err)
+		}
+	} else {
+		ts.cfg.EKSConfig.Status.ClusterOIDCProviderARN = aws.StringValue(out.OpenIDConnectProviderArn)
+		ts.cfg.Logger.Info("associated cluster OIDC provider with IAM",
+			zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCProviderARN),
+		)
+	}
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// oidcIssuerThumbprint computes the SHA-1 fingerprint of the OIDC issuer's
+// root CA certificate, the format IAM requires in
+// CreateOpenIDConnectProviderInput.ThumbprintList.
+func oidcIssuerThumbprint(issuerURL string) (string, error) {
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return "", err
+	}
+	host := u.Host
+	if !strings.Contains(host, ":") {
+		host += ":443"
+	}
+
+	conn, err := tls.Dial("tcp", host, &tls.Config{})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", fmt.Errorf("no TLS certificates returned by %q", host)
+	}
+	root := certs[len(certs)-1]
+	sum := sha1.Sum(root.Raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// createIRSARole ensures the cluster's OIDC provider exists, then provisions
+// (via CFN) the IAM role the Fargate test Pod's ServiceAccount assumes, and
+// finally creates that ServiceAccount annotated with the resulting role ARN.
+func (ts *tester) createIRSARole() error {
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA == nil || !ts.cfg.EKSConfig.AddOnFargate.IRSA.Enable {
+		return nil
+	}
+	if err := ts.ensureOIDCProvider(); err != nil {
+		return err
+	}
+
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID != "" {
+		ts.cfg.Logger.Info("Fargate IRSA role already created; no need to create a new one")
+		return ts.createIRSAServiceAccount()
+	}
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName == "" {
+		ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName = ts.cfg.EKSConfig.Name + "-fargate-irsa-role"
+	}
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName == "" {
+		ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName = ts.cfg.EKSConfig.Name + "-fargate-irsa-sa"
+	}
+
+	ts.cfg.Logger.Info("creating Fargate IRSA role via CFN", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName))
+	stackInput := &cloudformation.CreateStackInput{
+		StackName:    aws.String(ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName),
+		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM", "CAPABILITY_IAM"}),
+		OnFailure:    aws.String(cloudformation.OnFailureDelete),
+		TemplateBody: aws.String(TemplateFargateIRSARole),
+		Tags: awscfn.NewTags(map[string]string{
+			"Kind":                   "aws-k8s-tester",
+			"Name":                   ts.cfg.EKSConfig.Name,
+			"aws-k8s-tester-version": version.ReleaseVersion,
+		}),
+		Parameters: []*cloudformation.Parameter{
+			{
+				ParameterKey:   aws.String("ClusterOIDCProviderARN"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.Status.ClusterOIDCProviderARN),
+			},
+			{
+				ParameterKey:   aws.String("ClusterOIDCProviderURL"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.Status.ClusterOIDCProviderURL),
+			},
+			{
+				ParameterKey:   aws.String("ServiceAccountNamespace"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.AddOnFargate.Namespace),
+			},
+			{
+				ParameterKey:   aws.String("ServiceAccountName"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName),
+			},
+		},
+	}
+	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID = aws.StringValue(stackOutput.StackId)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ch := awscfn.Poll(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Sig,
+		ts.cfg.Logger,
+		ts.cfg.CFNAPI,
+		ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID,
+		cloudformation.ResourceStatusCreateComplete,
+		time.Minute,
+		10*time.Second,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			cancel()
+			ts.cfg.EKSConfig.RecordStatus(fmt.Sprintf("failed to create Fargate IRSA role (%v)", st.Error))
+			return st.Error
+		}
+	}
+	cancel()
+
+	for _, o := range st.Stack.Outputs {
+		switch k := aws.StringValue(o.OutputKey); k {
+		case "FargateIRSARoleARN":
+			ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleARN = aws.StringValue(o.OutputValue)
+		default:
+			return fmt.Errorf("unexpected OutputKey %q from %q", k, ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID)
+		}
+	}
+
+	ts.cfg.Logger.Info("created Fargate IRSA role", zap.String("role-arn", ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleARN))
+	if err := ts.cfg.EKSConfig.Sync(); err != nil {
+		return err
+	}
+	return ts.createIRSAServiceAccount()
+}
+
+func (ts *tester) createIRSAServiceAccount() error {
+	ts.cfg.Logger.Info("creating Fargate IRSA ServiceAccount", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName))
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		ServiceAccounts(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+		Create(&v1.ServiceAccount{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ServiceAccount",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName,
+				Namespace: ts.cfg.EKSConfig.AddOnFargate.Namespace,
+				Annotations: map[string]string{
+					"eks.amazonaws.com/role-arn": ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleARN,
+				},
+			},
+		})
+	if err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("created Fargate IRSA ServiceAccount")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// deleteIRSARole tears down the Fargate IRSA ServiceAccount and role CFN
+// stack, in that order (the ServiceAccount references the role, not the
+// other way around). It does not disassociate the cluster's OIDC provider,
+// since ensureOIDCProvider may have found -- and must leave intact -- one
+// that predates AddOnFargate.
+func (ts *tester) deleteIRSARole() error {
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA == nil || !ts.cfg.EKSConfig.AddOnFargate.IRSA.Enable {
+		return nil
+	}
+	if err := ts.deleteIRSAServiceAccount(); err != nil {
+		return err
+	}
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID == "" {
+		ts.cfg.Logger.Info("empty Fargate IRSA role CFN stack ID; no need to delete")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting Fargate IRSA role CFN stack",
+		zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID),
+	)
+	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+		StackName: aws.String(ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID),
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ch := awscfn.Poll(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Sig,
+		ts.cfg.Logger,
+		ts.cfg.CFNAPI,
+		ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleCFNStackID,
+		cloudformation.ResourceStatusDeleteComplete,
+		time.Minute,
+		10*time.Second,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			cancel()
+			ts.cfg.EKSConfig.RecordStatus(fmt.Sprintf("failed to delete Fargate IRSA role (%v)", st.Error))
+			return st.Error
+		}
+	}
+	cancel()
+	ts.cfg.Logger.Info("deleted Fargate IRSA role")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) deleteIRSAServiceAccount() error {
+	if ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Fargate IRSA ServiceAccount", zap.String("name", ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName))
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		ServiceAccounts(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+		Delete(
+			ts.cfg.EKSConfig.AddOnFargate.IRSA.ServiceAccountName,
+			&metav1.DeleteOptions{
+				GracePeriodSeconds: aws.Int64(0),
+				PropagationPolicy:  &propagationBackground,
+			},
+		)
+	if err != nil && !strings.Contains(err.Error(), " not found") {
+		return err
+	}
+	ts.cfg.Logger.Info("deleted Fargate IRSA ServiceAccount")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// checkIRSA execs "aws sts get-caller-identity" inside the test Pod's
+// irsa-check container and requires the assumed-role identity to name the
+// IRSA role, not the Fargate pod execution role.
+func (ts *tester) checkIRSA() error {
+	cmdFlags := []string{
+		"--namespace=" + ts.cfg.EKSConfig.AddOnFargate.Namespace,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"exec",
+		"-it",
+		ts.cfg.EKSConfig.AddOnFargate.PodName,
+		"-c", fargateIRSAContainerName,
+		"--",
+		"aws", "sts", "get-caller-identity",
+	}
+	ts.cfg.Logger.Info("checking Pod IRSA identity",
+		zap.String("command", ts.cfg.EKSConfig.KubectlPath+" "+strings.Join(cmdFlags, " ")),
+	)
+
+	var out string
+	retryStart, waitDur := time.Now(), 3*time.Minute
+	for time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("aborted")
+			return nil
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err := exec.New().CommandContext(
+			ctx,
+			ts.cfg.EKSConfig.KubectlPath,
+			cmdFlags...,
+		).CombinedOutput()
+		cancel()
+		out = string(output)
+		if err != nil {
+			ts.cfg.Logger.Warn("'kubectl exec' IRSA check failed", zap.String("output", out), zap.Error(err))
+			continue
+		}
+		if !strings.Contains(out, ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName) {
+			ts.cfg.Logger.Warn("Pod identity does not match IRSA role yet", zap.String("output", out))
+			continue
+		}
+
+		ts.cfg.Logger.Info("verified Pod assumed the IRSA role",
+			zap.String("role-name", ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName),
+			zap.String("output", out),
+		)
+		return ts.cfg.EKSConfig.Sync()
+	}
+
+	return fmt.Errorf("Pod did not assume IRSA role %q (last output: %q)", ts.cfg.EKSConfig.AddOnFargate.IRSA.RoleName, out)
+}
@@ -0,0 +1,134 @@
+package fargate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// listProfilesWorkers bounds how many DescribeFargateProfile calls
+// ListProfiles issues concurrently while hydrating a cluster's profile list.
+const listProfilesWorkers = 5
+
+// ListProfiles returns every Fargate profile in clusterName, fully hydrated
+// via DescribeFargateProfile, by paginating ListFargateProfilesPagesWithContext.
+func (ts *tester) ListProfiles(ctx context.Context, clusterName string) ([]*eks.FargateProfile, error) {
+	var names []string
+	err := ts.cfg.EKSAPI.ListFargateProfilesPagesWithContext(
+		ctx,
+		&eks.ListFargateProfilesInput{
+			ClusterName: aws.String(clusterName),
+			MaxResults:  aws.Int64(100),
+		},
+		func(out *eks.ListFargateProfilesOutput, lastPage bool) bool {
+			for _, n := range out.FargateProfileNames {
+				names = append(names, aws.StringValue(n))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fargate profiles for %q (%v)", clusterName, err)
+	}
+	return ts.describeProfiles(ctx, clusterName, names)
+}
+
+// describeProfiles hydrates each name via DescribeFargateProfile using a
+// bounded worker pool, since a cluster can have many profiles and
+// describing them one at a time would be slow.
+func (ts *tester) describeProfiles(ctx context.Context, clusterName string, names []string) ([]*eks.FargateProfile, error) {
+	type result struct {
+		idx     int
+		profile *eks.FargateProfile
+		err     error
+	}
+
+	sem := make(chan struct{}, listProfilesWorkers)
+	resultc := make(chan result, len(names))
+	for i, name := range names {
+		i, name := i, name
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			out, err := ts.cfg.EKSAPI.DescribeFargateProfileWithContext(ctx, &eks.DescribeFargateProfileInput{
+				ClusterName:        aws.String(clusterName),
+				FargateProfileName: aws.String(name),
+			})
+			if err != nil {
+				resultc <- result{idx: i, err: fmt.Errorf("failed to describe Fargate profile %q (%v)", name, err)}
+				return
+			}
+			resultc <- result{idx: i, profile: out.FargateProfile}
+		}()
+	}
+
+	profiles := make([]*eks.FargateProfile, len(names))
+	var firstErr error
+	for range names {
+		r := <-resultc
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		profiles[r.idx] = r.profile
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return profiles, nil
+}
+
+// ReconcileProfiles converges the cluster's additional Fargate profiles to
+// desired: any desired profile missing from the cluster is created, and any
+// existing non-default profile not named in desired is deleted through the
+// same serialized create/delete queue createProfileSpec/deleteProfileSpec
+// use. This lets a tester run idempotently converge a cluster instead of
+// failing outright when leftovers from a previous run are still present.
+func (ts *tester) ReconcileProfiles(ctx context.Context, desired []eksconfig.FargateProfileSpec) error {
+	actual, err := ts.ListProfiles(ctx, ts.cfg.EKSConfig.Name)
+	if err != nil {
+		return err
+	}
+
+	actualNames := make(map[string]bool, len(actual))
+	for _, p := range actual {
+		actualNames[aws.StringValue(p.FargateProfileName)] = true
+	}
+	desiredNames := make(map[string]bool, len(desired))
+	for _, spec := range desired {
+		desiredNames[spec.Name] = true
+	}
+
+	var toCreate []eksconfig.FargateProfileSpec
+	for _, spec := range desired {
+		if !actualNames[spec.Name] {
+			toCreate = append(toCreate, spec)
+		}
+	}
+	var toDelete []string
+	for name := range actualNames {
+		if name == ts.cfg.EKSConfig.AddOnFargate.ProfileName || desiredNames[name] {
+			continue
+		}
+		toDelete = append(toDelete, name)
+	}
+
+	for _, name := range toDelete {
+		ts.cfg.Logger.Info("pruning Fargate profile not in desired set", zap.String("name", name))
+		if err := ts.deleteProfileSpec(name); err != nil {
+			return err
+		}
+	}
+	for _, err := range ts.CreateProfiles(toCreate) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
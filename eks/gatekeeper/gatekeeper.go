@@ -0,0 +1,625 @@
+// Package gatekeeper implements tester for OPA Gatekeeper.
+package gatekeeper
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/exec"
+	"sigs.k8s.io/yaml"
+)
+
+// Config defines Gatekeeper configuration.
+type Config struct {
+	Logger    *zap.Logger
+	Stopc     chan struct{}
+	Sig       chan os.Signal
+	EKSConfig *eksconfig.Config
+	K8SClient k8sClientSetGetter
+}
+
+type k8sClientSetGetter interface {
+	KubernetesClientSet() *clientset.Clientset
+}
+
+// Tester defines Gatekeeper tester.
+type Tester interface {
+	// Create installs OPA Gatekeeper and its default policies.
+	Create() error
+	// Delete removes OPA Gatekeeper and its policies.
+	Delete() error
+}
+
+// New creates a new Gatekeeper tester.
+func New(cfg Config) (Tester, error) {
+	return &tester{cfg: cfg}, nil
+}
+
+type tester struct {
+	cfg Config
+	// appliedConstraints is populated by applyConstraints with every
+	// Constraint Create applied (its kind/name), so auditAndReport knows
+	// what to poll without re-deriving it from AddOnGatekeeper.TemplateDir.
+	appliedConstraints []constraintRef
+}
+
+// constraintRef identifies one applied Gatekeeper Constraint custom
+// resource, by the kind/name kubectl needs to fetch its audit status.
+type constraintRef struct {
+	Kind string
+	Name string
+}
+
+const (
+	gatekeeperManifestURL      = "https://raw.githubusercontent.com/open-policy-agent/gatekeeper/v3.1.0/deploy/gatekeeper.yaml"
+	requiredLabelsTemplateName = "k8srequiredlabels"
+	requiredLabelsConstraint   = "ns-must-have-team-label"
+	conformancePodName         = "gatekeeper-policy-conformance-pod"
+)
+
+// https://open-policy-agent.github.io/gatekeeper/website/docs/install/
+func (ts *tester) Create() (err error) {
+	if ts.cfg.EKSConfig.AddOnGatekeeper.Created {
+		ts.cfg.Logger.Info("skipping create AddOnGatekeeper")
+		return nil
+	}
+
+	ts.cfg.EKSConfig.AddOnGatekeeper.Created = true
+	ts.cfg.EKSConfig.Sync()
+	createStart := time.Now()
+	defer func() {
+		ts.cfg.EKSConfig.AddOnGatekeeper.CreateTook = time.Since(createStart)
+		ts.cfg.EKSConfig.AddOnGatekeeper.CreateTookString = ts.cfg.EKSConfig.AddOnGatekeeper.CreateTook.String()
+		ts.cfg.EKSConfig.Sync()
+
+		if err != nil && ts.cfg.EKSConfig.OnFailureDeletePartial {
+			ts.cfg.Logger.Warn("reverting partially created AddOnGatekeeper resources", zap.Error(err))
+			if derr := ts.Delete(); derr != nil {
+				ts.cfg.Logger.Warn("failed to revert partially created AddOnGatekeeper resources", zap.Error(derr))
+			}
+		}
+	}()
+
+	if err = ts.installGatekeeper(); err != nil {
+		return err
+	}
+	if err = ts.waitGatekeeperReady(); err != nil {
+		return err
+	}
+	if err = ts.applyConstraints(); err != nil {
+		return err
+	}
+
+	if ts.cfg.EKSConfig.AddOnGatekeeper.TemplateDir == "" {
+		if err = ts.runPolicyConformanceSuite(); err != nil {
+			return err
+		}
+	} else {
+		ts.cfg.Logger.Info("skipping built-in policy-conformance suite for custom AddOnGatekeeper.TemplateDir constraints")
+	}
+
+	if err = ts.auditAndReport(); err != nil {
+		return err
+	}
+
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.AddOnGatekeeper.Created {
+		ts.cfg.Logger.Info("skipping delete AddOnGatekeeper")
+		return nil
+	}
+
+	deleteStart := time.Now()
+	defer func() {
+		ts.cfg.EKSConfig.AddOnGatekeeper.DeleteTook = time.Since(deleteStart)
+		ts.cfg.EKSConfig.AddOnGatekeeper.DeleteTookString = ts.cfg.EKSConfig.AddOnGatekeeper.DeleteTook.String()
+		ts.cfg.EKSConfig.Sync()
+	}()
+
+	var errs []string
+	if dir := ts.cfg.EKSConfig.AddOnGatekeeper.TemplateDir; dir != "" {
+		pairs, err := loadConstraintPairs(dir)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to read AddOnGatekeeper.TemplateDir for cleanup (%v)", err))
+		}
+		for _, p := range pairs {
+			if err := ts.kubectlDelete(p.ConstraintName, p.ConstraintKind); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to delete constraint %s/%s (%v)", p.ConstraintKind, p.ConstraintName, err))
+			}
+		}
+	} else if err := ts.kubectlDelete(requiredLabelsConstraint, "k8srequiredlabels"); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete constraint (%v)", err))
+	}
+	if err := ts.kubectlDeleteURL(gatekeeperManifestURL); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Gatekeeper manifests (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.AddOnGatekeeper.Created = false
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// installGatekeeper applies the upstream Gatekeeper manifests (CRDs, webhook,
+// controller/audit Deployments) since client-go has no typed client for them.
+func (ts *tester) installGatekeeper() error {
+	ts.cfg.Logger.Info("installing OPA Gatekeeper", zap.String("manifest", gatekeeperManifestURL))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"apply",
+		"-f",
+		gatekeeperManifestURL,
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' Gatekeeper manifest failed (output %q, error %v)", string(output), err)
+	}
+	ts.cfg.Logger.Info("installed OPA Gatekeeper")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) waitGatekeeperReady() error {
+	ts.cfg.Logger.Info("waiting for Gatekeeper controller-manager Deployment")
+	ready := false
+	waitDur := 3 * time.Minute
+	retryStart := time.Now()
+	for time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check aborted")
+		case <-ts.cfg.Sig:
+			return errors.New("check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		dresp, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			Deployments(ts.cfg.EKSConfig.AddOnGatekeeper.Namespace).
+			Get("gatekeeper-controller-manager", metav1.GetOptions{})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Gatekeeper Deployment; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("get Gatekeeper Deployment",
+			zap.Int32("available-replicas", dresp.Status.AvailableReplicas),
+			zap.Int32("ready-replicas", dresp.Status.ReadyReplicas),
+		)
+		if dresp.Status.AvailableReplicas > 0 {
+			ready = true
+			break
+		}
+	}
+	if !ready {
+		return errors.New("Gatekeeper controller-manager Deployment not ready")
+	}
+
+	ts.cfg.Logger.Info("waited for Gatekeeper controller-manager Deployment")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// requiredLabelsConstraintTemplate requires a configurable set of labels on
+// Namespaces, mirroring the Gatekeeper library's "required labels" example.
+const requiredLabelsConstraintTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8srequiredlabels
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sRequiredLabels
+      validation:
+        openAPIV3Schema:
+          properties:
+            labels:
+              type: array
+              items: string
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8srequiredlabels
+
+        violation[{"msg": msg, "details": {"missing_labels": missing}}] {
+          provided := {label | input.review.object.metadata.labels[label]}
+          required := {label | label := input.parameters.labels[_]}
+          missing := required - provided
+          count(missing) > 0
+          msg := sprintf("you must provide labels: %v", [missing])
+        }
+`
+
+// constraintPair is one user-supplied ConstraintTemplate/Constraint pair
+// loaded from AddOnGatekeeper.TemplateDir, named "<base>-template.yaml" and
+// "<base>-constraint.yaml" respectively.
+type constraintPair struct {
+	TemplatePath   string
+	ConstraintPath string
+	ConstraintKind string
+	ConstraintName string
+}
+
+// loadConstraintPairs reads dir for "<base>-template.yaml"/"<base>-constraint.yaml"
+// pairs and resolves each constraint's kind/metadata.name so the caller can
+// kubectl get/delete it without re-parsing the YAML itself.
+func loadConstraintPairs(dir string) ([]constraintPair, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AddOnGatekeeper.TemplateDir %q (%v)", dir, err)
+	}
+
+	templates := make(map[string]string)
+	constraints := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch name := e.Name(); {
+		case strings.HasSuffix(name, "-template.yaml"):
+			templates[strings.TrimSuffix(name, "-template.yaml")] = filepath.Join(dir, name)
+		case strings.HasSuffix(name, "-constraint.yaml"):
+			constraints[strings.TrimSuffix(name, "-constraint.yaml")] = filepath.Join(dir, name)
+		}
+	}
+
+	pairs := make([]constraintPair, 0, len(templates))
+	for base, tplPath := range templates {
+		cPath, ok := constraints[base]
+		if !ok {
+			return nil, fmt.Errorf("%q has no matching %q in AddOnGatekeeper.TemplateDir %q", filepath.Base(tplPath), base+"-constraint.yaml", dir)
+		}
+
+		b, err := ioutil.ReadFile(cPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q (%v)", cPath, err)
+		}
+		var meta struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		if err = yaml.Unmarshal(b, &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse %q (%v)", cPath, err)
+		}
+		if meta.Kind == "" || meta.Metadata.Name == "" {
+			return nil, fmt.Errorf("%q is missing kind/metadata.name", cPath)
+		}
+
+		pairs = append(pairs, constraintPair{
+			TemplatePath:   tplPath,
+			ConstraintPath: cPath,
+			ConstraintKind: meta.Kind,
+			ConstraintName: meta.Metadata.Name,
+		})
+	}
+	return pairs, nil
+}
+
+// applyConstraints applies AddOnGatekeeper.TemplateDir's ConstraintTemplate/
+// Constraint pairs, or falls back to the built-in "required labels" demo
+// policy when TemplateDir is unset, and records what was applied in
+// ts.appliedConstraints for auditAndReport.
+func (ts *tester) applyConstraints() error {
+	dir := ts.cfg.EKSConfig.AddOnGatekeeper.TemplateDir
+	if dir == "" {
+		if err := ts.kubectlApplyInline("constraint-template", requiredLabelsConstraintTemplate); err != nil {
+			return err
+		}
+		labels := ts.cfg.EKSConfig.AddOnGatekeeper.RequiredLabels
+		if len(labels) == 0 {
+			labels = []string{"team"}
+		}
+		if err := ts.kubectlApplyInline("constraint", requiredLabelsConstraintYAML(labels)); err != nil {
+			return err
+		}
+		ts.appliedConstraints = []constraintRef{{Kind: "K8sRequiredLabels", Name: requiredLabelsConstraint}}
+		return nil
+	}
+
+	pairs, err := loadConstraintPairs(dir)
+	if err != nil {
+		return err
+	}
+
+	ts.appliedConstraints = make([]constraintRef, 0, len(pairs))
+	for _, p := range pairs {
+		tplBody, err := ioutil.ReadFile(p.TemplatePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %q (%v)", p.TemplatePath, err)
+		}
+		if err = ts.kubectlApplyInline("constraint-template-"+filepath.Base(p.TemplatePath), string(tplBody)); err != nil {
+			return err
+		}
+
+		constraintBody, err := ioutil.ReadFile(p.ConstraintPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %q (%v)", p.ConstraintPath, err)
+		}
+		if err = ts.kubectlApplyInline("constraint-"+filepath.Base(p.ConstraintPath), string(constraintBody)); err != nil {
+			return err
+		}
+
+		ts.appliedConstraints = append(ts.appliedConstraints, constraintRef{Kind: p.ConstraintKind, Name: p.ConstraintName})
+	}
+	return nil
+}
+
+func requiredLabelsConstraintYAML(labels []string) string {
+	quoted := make([]string, len(labels))
+	for i, l := range labels {
+		quoted[i] = `"` + l + `"`
+	}
+	return fmt.Sprintf(`
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: %s
+spec:
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Namespace"]
+  parameters:
+    labels: [%s]
+`, requiredLabelsConstraint, strings.Join(quoted, ", "))
+}
+
+// runPolicyConformanceSuite verifies the admission webhook actually rejects a
+// Namespace that violates the "required labels" constraint, and accepts one
+// that satisfies it.
+func (ts *tester) runPolicyConformanceSuite() error {
+	ts.cfg.Logger.Info("running Gatekeeper policy-conformance suite")
+
+	violatingNS := ts.cfg.EKSConfig.Name + "-gatekeeper-violating"
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Namespaces().
+		Create(&v1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: violatingNS},
+		})
+	if err == nil {
+		ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Namespaces().Delete(violatingNS, &metav1.DeleteOptions{GracePeriodSeconds: aws.Int64(0)})
+		return fmt.Errorf("expected admission webhook to reject Namespace %q without required labels, but it was created", violatingNS)
+	}
+	if !strings.Contains(err.Error(), "admission webhook") {
+		return fmt.Errorf("expected admission webhook rejection for Namespace %q, got (%v)", violatingNS, err)
+	}
+	ts.cfg.Logger.Info("confirmed policy rejects non-compliant Namespace", zap.Error(err))
+
+	compliantNS := ts.cfg.EKSConfig.Name + "-gatekeeper-compliant"
+	required := ts.cfg.EKSConfig.AddOnGatekeeper.RequiredLabels
+	if len(required) == 0 {
+		required = []string{"team"}
+	}
+	compliantLabels := make(map[string]string, len(required))
+	for _, l := range required {
+		compliantLabels[l] = "aws-k8s-tester"
+	}
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Namespaces().
+		Create(&v1.Namespace{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   compliantNS,
+				Labels: compliantLabels,
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("expected admission webhook to allow compliant Namespace %q (%v)", compliantNS, err)
+	}
+	defer ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Namespaces().Delete(compliantNS, &metav1.DeleteOptions{GracePeriodSeconds: aws.Int64(0)})
+
+	ts.cfg.Logger.Info("Gatekeeper policy-conformance suite passed")
+	return ts.cfg.EKSConfig.Sync()
+}
+
+// gatekeeperViolation is one row of the CSV report written to
+// AddOnGatekeeper.ResultPath.
+type gatekeeperViolation struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+// gatekeeperConstraintStatus is the subset of a Constraint custom resource's
+// status block that the audit controller populates.
+type gatekeeperConstraintStatus struct {
+	Status struct {
+		AuditTimestamp string `json:"auditTimestamp"`
+		Violations     []struct {
+			Kind      string `json:"kind"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+			Message   string `json:"message"`
+		} `json:"violations"`
+	} `json:"status"`
+}
+
+// auditAndReport waits for Gatekeeper's audit controller to evaluate every
+// constraint applied by applyConstraints, then writes the combined
+// violations to AddOnGatekeeper.ResultPath.
+func (ts *tester) auditAndReport() error {
+	var all []gatekeeperViolation
+	for _, ref := range ts.appliedConstraints {
+		vs, err := ts.waitConstraintAudited(ref.Kind, ref.Name)
+		if err != nil {
+			return err
+		}
+		all = append(all, vs...)
+	}
+	return ts.writeViolationsCSV(all)
+}
+
+// waitConstraintAudited polls "kubectl get <kind> <name> -o json" until
+// status.auditTimestamp is set, meaning the audit controller has evaluated
+// the constraint at least once, then returns its status.violations.
+func (ts *tester) waitConstraintAudited(kind, name string) ([]gatekeeperViolation, error) {
+	ts.cfg.Logger.Info("waiting for Gatekeeper audit to evaluate constraint", zap.String("kind", kind), zap.String("name", name))
+	waitDur := 3 * time.Minute
+	retryStart := time.Now()
+	for time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("check aborted")
+		case <-ts.cfg.Sig:
+			return nil, errors.New("check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		output, err := exec.New().CommandContext(
+			ctx,
+			ts.cfg.EKSConfig.KubectlPath,
+			"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+			"get",
+			kind,
+			name,
+			"-o",
+			"json",
+		).CombinedOutput()
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get constraint status; retrying", zap.String("kind", kind), zap.String("name", name), zap.Error(err))
+			continue
+		}
+
+		var cs gatekeeperConstraintStatus
+		if err = json.Unmarshal(output, &cs); err != nil {
+			ts.cfg.Logger.Warn("failed to parse constraint status; retrying", zap.String("kind", kind), zap.String("name", name), zap.Error(err))
+			continue
+		}
+		if cs.Status.AuditTimestamp == "" {
+			continue
+		}
+
+		violations := make([]gatekeeperViolation, 0, len(cs.Status.Violations))
+		for _, v := range cs.Status.Violations {
+			violations = append(violations, gatekeeperViolation{
+				Kind:      kind,
+				Namespace: v.Namespace,
+				Name:      v.Name,
+				Message:   v.Message,
+			})
+		}
+		ts.cfg.Logger.Info("constraint audited", zap.String("kind", kind), zap.String("name", name), zap.Int("violations", len(violations)))
+		return violations, nil
+	}
+	return nil, fmt.Errorf("timed out waiting for Gatekeeper audit to evaluate %s/%s", kind, name)
+}
+
+// writeViolationsCSV writes every violation found across all applied
+// constraints to AddOnGatekeeper.ResultPath, mirroring AddOnCSI.ResultPath's
+// CSV report convention.
+func (ts *tester) writeViolationsCSV(violations []gatekeeperViolation) error {
+	path := ts.cfg.EKSConfig.AddOnGatekeeper.ResultPath
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create AddOnGatekeeper.ResultPath %q (%v)", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err = w.Write([]string{"kind", "namespace", "name", "message"}); err != nil {
+		return err
+	}
+	for _, v := range violations {
+		if err = w.Write([]string{v.Kind, v.Namespace, v.Name, v.Message}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("wrote Gatekeeper violations report", zap.String("path", path), zap.Int("violations", len(violations)))
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) kubectlApplyInline(label, manifest string) error {
+	f, err := os.CreateTemp("", "gatekeeper-"+label+"-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(manifest); err != nil {
+		return err
+	}
+	f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"apply",
+		"-f",
+		f.Name(),
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' %s failed (output %q, error %v)", label, string(output), err)
+	}
+	return nil
+}
+
+func (ts *tester) kubectlDelete(name, kind string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		kind,
+		name,
+		"--ignore-not-found",
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl delete' %s/%s failed (output %q, error %v)", kind, name, string(output), err)
+	}
+	return nil
+}
+
+func (ts *tester) kubectlDeleteURL(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"-f",
+		url,
+		"--ignore-not-found",
+	).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl delete' Gatekeeper manifest failed (output %q, error %v)", string(output), err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package ng
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"go.uber.org/zap"
+)
+
+// sgRule is the Go-encoded equivalent of one ingress/egress rule in
+// TemplateSG. reconcileSG diffs the desired set of these against what EC2
+// actually has on the running security groups and issues targeted
+// Authorize/Revoke calls, so changing a port never requires recreating the
+// CFN stack (and thus the SG ID, and thus the node launch template).
+type sgRule struct {
+	groupID     string
+	egress      bool
+	protocol    string
+	fromPort    int64
+	toPort      int64
+	cidrIP      string
+	sourceGroup string
+	description string
+}
+
+// desiredSGRules returns the rule set TemplateSG would have produced for the
+// node group security groups that already exist (ts.cfg.EKSConfig.AddOnNodeGroups.*
+// SecurityGroupID fields), so it can only be called after createSG has run at
+// least once.
+func (ts *tester) desiredSGRules() []sgRule {
+	ng := ts.cfg.EKSConfig.AddOnNodeGroups
+	cp := ts.cfg.EKSConfig.Parameters.ControlPlaneSecurityGroupID
+
+	rules := []sgRule{
+		{
+			groupID: ng.NodeGroupSecurityGroupID, protocol: "-1", fromPort: 0, toPort: 65535,
+			sourceGroup: ng.NodeGroupSecurityGroupID,
+			description: "Allow node to communicate with each other",
+		},
+		{
+			groupID: cp, protocol: "tcp", fromPort: 443, toPort: 443,
+			sourceGroup: ng.ControlPlaneFacingSecurityGroupID,
+			description: "Allow pods to communicate with the cluster API Server",
+		},
+		{
+			groupID: ng.ControlPlaneFacingSecurityGroupID, protocol: "tcp", fromPort: 443, toPort: 443,
+			sourceGroup: cp,
+			description: "Allow pods running extension API servers on port 443 to receive communication from cluster control plane",
+		},
+		{
+			groupID: cp, egress: true, protocol: "tcp", fromPort: 443, toPort: 443,
+			sourceGroup: ng.ControlPlaneFacingSecurityGroupID,
+			description: "Allow the cluster control plane to communicate with pods running extension API servers on port 443",
+		},
+		{
+			groupID: ng.ControlPlaneFacingSecurityGroupID, protocol: "tcp", fromPort: 1025, toPort: 65535,
+			sourceGroup: cp,
+			description: "Allow worker Kubelets and pods to receive communication from the cluster control plane",
+		},
+		{
+			groupID: cp, egress: true, protocol: "tcp", fromPort: 1025, toPort: 65535,
+			sourceGroup: ng.ControlPlaneFacingSecurityGroupID,
+			description: "Allow the cluster control plane to communicate with worker Kubelet and pods",
+		},
+		{
+			groupID: ng.NodeGroupSecurityGroupID, protocol: "tcp", fromPort: 22, toPort: 22,
+			sourceGroup: ng.BastionSecurityGroupID,
+			description: "Allow the bastion host to SSH into nodes",
+		},
+		{
+			groupID: cp, protocol: "tcp", fromPort: 443, toPort: 443,
+			sourceGroup: ng.APIServerLBSecurityGroupID,
+			description: "Allow the API server load balancer to reach the control plane",
+		},
+		{
+			groupID: ng.NodeGroupSecurityGroupID, protocol: "tcp", fromPort: 1, toPort: 65535,
+			sourceGroup: ng.WorkerLBSecurityGroupID,
+			description: "Allow workload load balancers (ALB/NLB) to reach NodePort services",
+		},
+	}
+
+	if ng.RemoteAccess.Public {
+		for _, cidr := range ng.RemoteAccess.SourceCIDRs {
+			rules = append(rules, sgRule{
+				groupID: ng.BastionSecurityGroupID, protocol: "tcp", fromPort: 22, toPort: 22,
+				cidrIP:      cidr,
+				description: "Allow SSH from an allow-listed CIDR",
+			})
+		}
+		for _, sgID := range ng.RemoteAccess.SourceSecurityGroupIDs {
+			rules = append(rules, sgRule{
+				groupID: ng.BastionSecurityGroupID, protocol: "tcp", fromPort: 22, toPort: 22,
+				sourceGroup: sgID,
+				description: "Allow SSH from an allow-listed security group",
+			})
+		}
+	}
+
+	if ng.EnableGuestBookPorts {
+		rules = append(rules,
+			sgRule{
+				groupID: ng.NodeGroupSecurityGroupID, protocol: "tcp", fromPort: 1, toPort: 1024,
+				cidrIP:      "0.0.0.0/0",
+				description: "guestbook",
+			},
+			sgRule{
+				groupID: cp, protocol: "tcp", fromPort: 1, toPort: 1024,
+				cidrIP:      "0.0.0.0/0",
+				description: "guestbook",
+			},
+		)
+	}
+
+	return rules
+}
+
+// actualSGRules queries EC2 for every ingress/egress rule currently attached
+// to groupID and decodes them into the same sgRule shape as desiredSGRules,
+// so the two can be diffed directly.
+func (ts *tester) actualSGRules(groupID string) ([]sgRule, error) {
+	if groupID == "" {
+		return nil, nil
+	}
+	out, err := ts.cfg.EC2API.DescribeSecurityGroupRules(&ec2.DescribeSecurityGroupRulesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("group-id"),
+				Values: aws.StringSlice([]string{groupID}),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe security group rules for %q (%v)", groupID, err)
+	}
+
+	var rules []sgRule
+	for _, r := range out.SecurityGroupRules {
+		rule := sgRule{
+			groupID:  groupID,
+			egress:   aws.BoolValue(r.IsEgress),
+			protocol: aws.StringValue(r.IpProtocol),
+			fromPort: aws.Int64Value(r.FromPort),
+			toPort:   aws.Int64Value(r.ToPort),
+		}
+		if r.CidrIpv4 != nil {
+			rule.cidrIP = aws.StringValue(r.CidrIpv4)
+		}
+		if r.ReferencedGroupInfo != nil {
+			rule.sourceGroup = aws.StringValue(r.ReferencedGroupInfo.GroupId)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r sgRule) key() string {
+	return fmt.Sprintf("%s|%v|%s|%d|%d|%s|%s", r.groupID, r.egress, r.protocol, r.fromPort, r.toPort, r.cidrIP, r.sourceGroup)
+}
+
+// reconcileSG diffs the desired rule set against what EC2 actually has on the
+// node group security groups and issues targeted Authorize/Revoke calls, so
+// the security group IDs (and any node launch template referencing them)
+// never have to be recreated just to add or remove a port.
+func (ts *tester) reconcileSG() error {
+	desired := ts.desiredSGRules()
+
+	byGroup := make(map[string][]sgRule)
+	for _, r := range desired {
+		byGroup[r.groupID] = append(byGroup[r.groupID], r)
+	}
+
+	for groupID, want := range byGroup {
+		have, err := ts.actualSGRules(groupID)
+		if err != nil {
+			return err
+		}
+
+		haveByKey := make(map[string]sgRule, len(have))
+		for _, r := range have {
+			haveByKey[r.key()] = r
+		}
+		wantByKey := make(map[string]sgRule, len(want))
+		for _, r := range want {
+			wantByKey[r.key()] = r
+		}
+
+		for k, r := range wantByKey {
+			if _, ok := haveByKey[k]; !ok {
+				if err := ts.authorizeSGRule(r); err != nil {
+					return err
+				}
+			}
+		}
+		for k, r := range haveByKey {
+			if _, ok := wantByKey[k]; !ok {
+				if err := ts.revokeSGRule(r); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	ts.cfg.Logger.Info("reconciled node group security group rules via diff", zap.Int("group-count", len(byGroup)))
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) authorizeSGRule(r sgRule) error {
+	perm := &ec2.IpPermission{
+		IpProtocol: aws.String(r.protocol),
+		FromPort:   aws.Int64(r.fromPort),
+		ToPort:     aws.Int64(r.toPort),
+	}
+	if r.cidrIP != "" {
+		perm.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(r.cidrIP), Description: aws.String(r.description)}}
+	}
+	if r.sourceGroup != "" {
+		perm.UserIdGroupPairs = []*ec2.UserIdGroupPair{{GroupId: aws.String(r.sourceGroup), Description: aws.String(r.description)}}
+	}
+
+	ts.cfg.Logger.Info("authorizing security group rule",
+		zap.String("group-id", r.groupID),
+		zap.Bool("egress", r.egress),
+		zap.String("protocol", r.protocol),
+		zap.Int64("from-port", r.fromPort),
+		zap.Int64("to-port", r.toPort),
+	)
+	var err error
+	if r.egress {
+		_, err = ts.cfg.EC2API.AuthorizeSecurityGroupEgress(&ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(r.groupID),
+			IpPermissions: []*ec2.IpPermission{perm},
+		})
+	} else {
+		_, err = ts.cfg.EC2API.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(r.groupID),
+			IpPermissions: []*ec2.IpPermission{perm},
+		})
+	}
+	return err
+}
+
+func (ts *tester) revokeSGRule(r sgRule) error {
+	perm := &ec2.IpPermission{
+		IpProtocol: aws.String(r.protocol),
+		FromPort:   aws.Int64(r.fromPort),
+		ToPort:     aws.Int64(r.toPort),
+	}
+	if r.cidrIP != "" {
+		perm.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(r.cidrIP)}}
+	}
+	if r.sourceGroup != "" {
+		perm.UserIdGroupPairs = []*ec2.UserIdGroupPair{{GroupId: aws.String(r.sourceGroup)}}
+	}
+
+	ts.cfg.Logger.Info("revoking security group rule",
+		zap.String("group-id", r.groupID),
+		zap.Bool("egress", r.egress),
+		zap.String("protocol", r.protocol),
+		zap.Int64("from-port", r.fromPort),
+		zap.Int64("to-port", r.toPort),
+	)
+	var err error
+	if r.egress {
+		_, err = ts.cfg.EC2API.RevokeSecurityGroupEgress(&ec2.RevokeSecurityGroupEgressInput{
+			GroupId:       aws.String(r.groupID),
+			IpPermissions: []*ec2.IpPermission{perm},
+		})
+	} else {
+		_, err = ts.cfg.EC2API.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(r.groupID),
+			IpPermissions: []*ec2.IpPermission{perm},
+		})
+	}
+	return err
+}
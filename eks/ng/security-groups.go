@@ -4,23 +4,36 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/eksconfig"
 	awscfn "github.com/aws/aws-k8s-tester/pkg/aws/cloudformation"
 	"github.com/aws/aws-k8s-tester/version"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"go.uber.org/zap"
 )
 
-// TemplateSG is the CloudFormation template for EKS node group security group.
+// TemplateSG is the CloudFormation template for EKS node group security groups.
+// Following the cluster-api-provider-aws model, a single monolithic node
+// security group is split into role-scoped groups (bastion, API server LB,
+// worker LB, control-plane-facing, node) so each role can be given
+// least-privilege rules instead of sharing one catch-all group.
 // ref. https://docs.aws.amazon.com/eks/latest/userguide/launch-workers.html
 // ref. https://github.com/awslabs/amazon-eks-ami/blob/master/amazon-eks-nodegroup.yaml
 // ref. https://github.com/aws/aws-k8s-tester/pull/33
+// ref. https://github.com/kubernetes-sigs/cluster-api-provider-aws/blob/master/pkg/cloud/services/securitygroup
+//
+// The "##DYNAMIC_RESOURCES##" marker is replaced by renderTemplateSG with the
+// SSH ingress and guestbook-port rules, whose shape depends on
+// AddOnNodeGroups.RemoteAccess and AddOnNodeGroups.EnableGuestBookPorts and so
+// cannot be expressed as a fixed set of CFN resources.
 const TemplateSG = `
 ---
 AWSTemplateFormatVersion: '2010-09-09'
-Description: 'Amazon EKS Node Group Security Group'
+Description: 'Amazon EKS Node Group Security Groups (role-scoped)'
 
 Parameters:
 
@@ -36,9 +49,14 @@ Parameters:
     Type: AWS::EC2::VPC::Id
     Description: The VPC of the worker instances
 
+  AdditionalSecurityGroupIDs:
+    Type: CommaDelimitedList
+    Default: ''
+    Description: Additional security group IDs to layer onto the created node security group
+
 Resources:
 
-  NodeSecurityGroup:
+  SecurityGroupNode:
     Type: AWS::EC2::SecurityGroup
     Properties:
       GroupDescription: Security group for all nodes in the cluster
@@ -47,23 +65,59 @@ Resources:
         Value: owned
       VpcId: !Ref VPCID
 
+  SecurityGroupControlPlane:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      GroupDescription: Security group for node<->control-plane traffic, attached alongside SecurityGroupNode on every worker instance
+      Tags:
+      - Key: !Sub kubernetes.io/cluster/${ClusterName}
+        Value: owned
+      VpcId: !Ref VPCID
+
+  SecurityGroupBastion:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      GroupDescription: Security group for the bastion host used to reach nodes over SSH
+      Tags:
+      - Key: !Sub kubernetes.io/cluster/${ClusterName}
+        Value: owned
+      VpcId: !Ref VPCID
+
+  SecurityGroupAPIServerLB:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      GroupDescription: Security group for the load balancer fronting the Kubernetes API server
+      Tags:
+      - Key: !Sub kubernetes.io/cluster/${ClusterName}
+        Value: owned
+      VpcId: !Ref VPCID
+
+  SecurityGroupLB:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      GroupDescription: Security group for load balancers fronting workloads (ALB/NLB add-ons)
+      Tags:
+      - Key: !Sub kubernetes.io/cluster/${ClusterName}
+        Value: owned
+      VpcId: !Ref VPCID
+
   IngressWithinNodeSecurityGroup:
     Type: AWS::EC2::SecurityGroupIngress
-    DependsOn: NodeSecurityGroup
+    DependsOn: SecurityGroupNode
     Properties:
       Description: Allow node to communicate with each other
-      GroupId: !Ref NodeSecurityGroup
-      SourceSecurityGroupId: !Ref NodeSecurityGroup
+      GroupId: !Ref SecurityGroupNode
+      SourceSecurityGroupId: !Ref SecurityGroupNode
       IpProtocol: "-1"
       FromPort: 0
       ToPort: 65535
 
   Ingress443FromNGtoCP:
     Type: AWS::EC2::SecurityGroupIngress
-    DependsOn: NodeSecurityGroup
+    DependsOn: SecurityGroupControlPlane
     Properties:
       Description: Allow pods to communicate with the cluster API Server
-      SourceSecurityGroupId: !Ref NodeSecurityGroup
+      SourceSecurityGroupId: !Ref SecurityGroupControlPlane
       GroupId: !Ref ControlPlaneSecurityGroupID
       IpProtocol: tcp
       FromPort: 443
@@ -71,61 +125,156 @@ Resources:
 
   Ingress443FromCPtoNG:
     Type: AWS::EC2::SecurityGroupIngress
-    DependsOn: NodeSecurityGroup
+    DependsOn: SecurityGroupControlPlane
     Properties:
       Description: Allow pods running extension API servers on port 443 to receive communication from cluster control plane
       SourceSecurityGroupId: !Ref ControlPlaneSecurityGroupID
-      GroupId: !Ref NodeSecurityGroup
+      GroupId: !Ref SecurityGroupControlPlane
       IpProtocol: tcp
       FromPort: 443
       ToPort: 443
 
   Egress443FromCPtoNG:
     Type: AWS::EC2::SecurityGroupEgress
-    DependsOn: NodeSecurityGroup
+    DependsOn: SecurityGroupControlPlane
     Properties:
       Description: Allow the cluster control plane to communicate with pods running extension API servers on port 443
       GroupId: !Ref ControlPlaneSecurityGroupID
-      DestinationSecurityGroupId: !Ref NodeSecurityGroup
+      DestinationSecurityGroupId: !Ref SecurityGroupControlPlane
       IpProtocol: tcp
       FromPort: 443
       ToPort: 443
 
   Ingress1025FromCPtoNG:
     Type: AWS::EC2::SecurityGroupIngress
-    DependsOn: NodeSecurityGroup
+    DependsOn: SecurityGroupControlPlane
     Properties:
       Description: Allow worker Kubelets and pods to receive communication from the cluster control plane
       SourceSecurityGroupId: !Ref ControlPlaneSecurityGroupID
-      GroupId: !Ref NodeSecurityGroup
+      GroupId: !Ref SecurityGroupControlPlane
       IpProtocol: tcp
       FromPort: 1025
       ToPort: 65535
 
   Egress1025FromCPtoNG:
     Type: AWS::EC2::SecurityGroupEgress
-    DependsOn: NodeSecurityGroup
+    DependsOn: SecurityGroupControlPlane
     Properties:
       Description: Allow the cluster control plane to communicate with worker Kubelet and pods
       GroupId: !Ref ControlPlaneSecurityGroupID
-      DestinationSecurityGroupId: !Ref NodeSecurityGroup
+      DestinationSecurityGroupId: !Ref SecurityGroupControlPlane
       IpProtocol: tcp
       FromPort: 1025
       ToPort: 65535
 
-  Ingress22ForSSH:
+  Ingress22FromBastionToNode:
     Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: [SecurityGroupBastion, SecurityGroupNode]
     Properties:
-      GroupId: !Ref NodeSecurityGroup
-      IpProtocol: 'tcp'
-      FromPort: '22'
-      ToPort: '22'
-      CidrIp: '0.0.0.0/0'
+      Description: Allow the bastion host to SSH into nodes
+      SourceSecurityGroupId: !Ref SecurityGroupBastion
+      GroupId: !Ref SecurityGroupNode
+      IpProtocol: tcp
+      FromPort: 22
+      ToPort: 22
+
+  IngressFromAPIServerLBToControlPlane:
+    Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: [SecurityGroupAPIServerLB, SecurityGroupControlPlane]
+    Properties:
+      Description: Allow the API server load balancer to reach the control plane
+      SourceSecurityGroupId: !Ref SecurityGroupAPIServerLB
+      GroupId: !Ref ControlPlaneSecurityGroupID
+      IpProtocol: tcp
+      FromPort: 443
+      ToPort: 443
+
+  IngressFromLBToNode:
+    Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: [SecurityGroupLB, SecurityGroupNode]
+    Properties:
+      Description: Allow workload load balancers (ALB/NLB) to reach NodePort services
+      SourceSecurityGroupId: !Ref SecurityGroupLB
+      GroupId: !Ref SecurityGroupNode
+      IpProtocol: tcp
+      FromPort: 1
+      ToPort: 65535
+
+##DYNAMIC_RESOURCES##
+Outputs:
+
+  NodeSecurityGroupID:
+    Value: !Ref SecurityGroupNode
+    Description: The security group ID for the node group
+
+  ControlPlaneFacingSecurityGroupID:
+    Value: !Ref SecurityGroupControlPlane
+    Description: The security group ID attached to nodes for control-plane-facing traffic
+
+  BastionSecurityGroupID:
+    Value: !Ref SecurityGroupBastion
+    Description: The security group ID for the bastion host
 
+  APIServerLBSecurityGroupID:
+    Value: !Ref SecurityGroupAPIServerLB
+    Description: The security group ID for the API server load balancer
+
+  WorkerLBSecurityGroupID:
+    Value: !Ref SecurityGroupLB
+    Description: The security group ID for workload (ALB/NLB) load balancers
+
+  AdditionalSecurityGroupIDs:
+    Value: !Join [",", !Ref AdditionalSecurityGroupIDs]
+    Description: The additional security group IDs layered onto the node group
+
+`
+
+// renderTemplateSG fills in the "##DYNAMIC_RESOURCES##" marker in TemplateSG
+// with the SSH ingress and guestbook-port rules appropriate for cfg: SSH
+// ingress is rendered only when RemoteAccess.Public is set (and only from its
+// allow-listed CIDRs/security groups), and the world-open 1-1024 guestbook
+// rules only when EnableGuestBookPorts is set.
+func renderTemplateSG(cfg *eksconfig.Config) string {
+	var sb strings.Builder
+
+	if cfg.AddOnNodeGroups.RemoteAccess.Public {
+		for i, cidr := range cfg.AddOnNodeGroups.RemoteAccess.SourceCIDRs {
+			fmt.Fprintf(&sb, `
+  Ingress22FromCIDR%d:
+    Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: SecurityGroupBastion
+    Properties:
+      Description: Allow SSH from an allow-listed CIDR
+      GroupId: !Ref SecurityGroupBastion
+      IpProtocol: tcp
+      FromPort: 22
+      ToPort: 22
+      CidrIp: '%s'
+`, i, cidr)
+		}
+		for i, sgID := range cfg.AddOnNodeGroups.RemoteAccess.SourceSecurityGroupIDs {
+			fmt.Fprintf(&sb, `
+  Ingress22FromSG%d:
+    Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: SecurityGroupBastion
+    Properties:
+      Description: Allow SSH from an allow-listed security group
+      GroupId: !Ref SecurityGroupBastion
+      IpProtocol: tcp
+      FromPort: 22
+      ToPort: 22
+      SourceSecurityGroupId: '%s'
+`, i, sgID)
+		}
+	}
+
+	if cfg.AddOnNodeGroups.EnableGuestBookPorts {
+		sb.WriteString(`
   Ingress1024ForGuestBook:
     Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: SecurityGroupNode
     Properties:
-      GroupId: !Ref NodeSecurityGroup
+      GroupId: !Ref SecurityGroupNode
       IpProtocol: 'tcp'
       FromPort: '1'
       ToPort: '1024'
@@ -133,33 +282,121 @@ Resources:
 
   Egress1024ForGuestBook:
     Type: AWS::EC2::SecurityGroupIngress
+    DependsOn: SecurityGroupControlPlane
     Properties:
       GroupId: !Ref ControlPlaneSecurityGroupID
       IpProtocol: 'tcp'
       FromPort: '1'
       ToPort: '1024'
       CidrIp: '0.0.0.0/0'
+`)
+	}
+
+	return strings.Replace(TemplateSG, "##DYNAMIC_RESOURCES##", sb.String(), 1)
+}
+
+// TemplateSGRules is a smaller CFN template that only reconciles the
+// ingress rules required between the control plane and a shared,
+// user-owned node security group, without owning (and thus without ever
+// deleting) the security group itself. Used when NodeGroupSecurityGroupCreate
+// is false and NodeGroupSecurityGroupReconcileRules is true.
+const TemplateSGRules = `
+---
+AWSTemplateFormatVersion: '2010-09-09'
+Description: 'Amazon EKS Node Group Security Group Rules (shared SG)'
+
+Parameters:
+
+  ControlPlaneSecurityGroupID:
+    Type: AWS::EC2::SecurityGroup::Id
+    Description: The security group of the cluster control plane.
+
+  NodeSecurityGroupID:
+    Type: AWS::EC2::SecurityGroup::Id
+    Description: The pre-existing, user-owned node security group.
+
+Resources:
+
+  IngressWithinNodeSecurityGroup:
+    Type: AWS::EC2::SecurityGroupIngress
+    Properties:
+      Description: Allow node to communicate with each other
+      GroupId: !Ref NodeSecurityGroupID
+      SourceSecurityGroupId: !Ref NodeSecurityGroupID
+      IpProtocol: "-1"
+      FromPort: 0
+      ToPort: 65535
+
+  Ingress443FromNGtoCP:
+    Type: AWS::EC2::SecurityGroupIngress
+    Properties:
+      Description: Allow pods to communicate with the cluster API Server
+      SourceSecurityGroupId: !Ref NodeSecurityGroupID
+      GroupId: !Ref ControlPlaneSecurityGroupID
+      IpProtocol: tcp
+      FromPort: 443
+      ToPort: 443
+
+  Ingress443FromCPtoNG:
+    Type: AWS::EC2::SecurityGroupIngress
+    Properties:
+      Description: Allow pods running extension API servers on port 443 to receive communication from cluster control plane
+      SourceSecurityGroupId: !Ref ControlPlaneSecurityGroupID
+      GroupId: !Ref NodeSecurityGroupID
+      IpProtocol: tcp
+      FromPort: 443
+      ToPort: 443
+
+  Ingress1025FromCPtoNG:
+    Type: AWS::EC2::SecurityGroupIngress
+    Properties:
+      Description: Allow worker Kubelets and pods to receive communication from the cluster control plane
+      SourceSecurityGroupId: !Ref ControlPlaneSecurityGroupID
+      GroupId: !Ref NodeSecurityGroupID
+      IpProtocol: tcp
+      FromPort: 1025
+      ToPort: 65535
 
 Outputs:
 
   NodeSecurityGroupID:
-    Value: !Ref NodeSecurityGroup
-    Description: The security group ID for the node group
+    Value: !Ref NodeSecurityGroupID
+    Description: The pre-existing node security group ID the rules were reconciled against
 
 `
 
 func (ts *tester) createSG() error {
+	if !ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCreate {
+		sgID := ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupIDs[0]
+		ts.cfg.Logger.Info("NodeGroupSecurityGroupCreate false; using shared node group security group",
+			zap.Strings("security-group-ids", ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupIDs),
+		)
+		if _, err := ts.cfg.EC2API.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+			GroupIds: aws.StringSlice([]string{sgID}),
+		}); err != nil {
+			return fmt.Errorf("shared node group security group %q does not exist or is not accessible (%v)", sgID, err)
+		}
+		ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupID = sgID
+
+		if ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupReconcileRules {
+			if err := ts.reconcileSGRules(sgID); err != nil {
+				return err
+			}
+		}
+		return ts.cfg.EKSConfig.Sync()
+	}
+
 	if ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCFNStackID != "" &&
 		ts.cfg.EKSConfig.Parameters.ControlPlaneSecurityGroupID != "" {
-		ts.cfg.Logger.Info("security group already created; no need to create a new one")
-		return nil
+		ts.cfg.Logger.Info("security group already created; reconciling rules in place instead of recreating the stack")
+		return ts.reconcileSG()
 	}
 
 	ts.cfg.Logger.Info("creating a new node group security group using CFN", zap.String("name", ts.cfg.EKSConfig.AddOnNodeGroups.RoleName))
 	stackInput := &cloudformation.CreateStackInput{
 		StackName:    aws.String(ts.cfg.EKSConfig.Name + "-ng-sg"),
 		OnFailure:    aws.String(cloudformation.OnFailureDelete),
-		TemplateBody: aws.String(TemplateSG),
+		TemplateBody: aws.String(renderTemplateSG(ts.cfg.EKSConfig)),
 		Tags: awscfn.NewTags(map[string]string{
 			"Kind":                   "aws-k8s-tester",
 			"Name":                   ts.cfg.EKSConfig.Name,
@@ -180,6 +417,15 @@ func (ts *tester) createSG() error {
 			},
 		},
 	}
+	if len(ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupAdditionalSecurityGroupIDs) > 0 {
+		ts.cfg.Logger.Info("layering additional security groups onto the node group",
+			zap.Strings("security-group-ids", ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupAdditionalSecurityGroupIDs),
+		)
+		stackInput.Parameters = append(stackInput.Parameters, &cloudformation.Parameter{
+			ParameterKey:   aws.String("AdditionalSecurityGroupIDs"),
+			ParameterValue: aws.String(strings.Join(ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupAdditionalSecurityGroupIDs, ",")),
+		})
+	}
 
 	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
 	if err != nil {
@@ -187,7 +433,7 @@ func (ts *tester) createSG() error {
 	}
 	ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCFNStackID = aws.StringValue(stackOutput.StackId)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.EKSConfig.AddOnNodeGroups.SGCreateTimeout)
 	ch := awscfn.Poll(
 		ctx,
 		ts.cfg.Stopc,
@@ -196,8 +442,8 @@ func (ts *tester) createSG() error {
 		ts.cfg.CFNAPI,
 		ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCFNStackID,
 		cloudformation.ResourceStatusCreateComplete,
-		time.Minute,
-		10*time.Second,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInterval,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInitialWait,
 	)
 	var st awscfn.StackStatus
 	for st = range ch {
@@ -213,6 +459,21 @@ func (ts *tester) createSG() error {
 		switch k := aws.StringValue(o.OutputKey); k {
 		case "NodeSecurityGroupID":
 			ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupID = aws.StringValue(o.OutputValue)
+		case "ControlPlaneFacingSecurityGroupID":
+			// attached alongside NodeGroupSecurityGroupID; consumed by the node
+			// launch template so control-plane<->node rules stay off SecurityGroupNode
+			ts.cfg.EKSConfig.AddOnNodeGroups.ControlPlaneFacingSecurityGroupID = aws.StringValue(o.OutputValue)
+		case "BastionSecurityGroupID":
+			// consumed by the bastion host launch config for SSH ingress
+			ts.cfg.EKSConfig.AddOnNodeGroups.BastionSecurityGroupID = aws.StringValue(o.OutputValue)
+		case "APIServerLBSecurityGroupID":
+			// consumed by whichever load balancer fronts the API server
+			ts.cfg.EKSConfig.AddOnNodeGroups.APIServerLBSecurityGroupID = aws.StringValue(o.OutputValue)
+		case "WorkerLBSecurityGroupID":
+			// consumed by the ALB/NLB add-ons instead of NodeGroupSecurityGroupID
+			ts.cfg.EKSConfig.AddOnNodeGroups.WorkerLBSecurityGroupID = aws.StringValue(o.OutputValue)
+		case "AdditionalSecurityGroupIDs":
+			// already known; CFN just echoes the input back
 		default:
 			return fmt.Errorf("unexpected OutputKey %q from %q", k, ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCFNStackID)
 		}
@@ -225,7 +486,120 @@ func (ts *tester) createSG() error {
 	return ts.cfg.EKSConfig.Sync()
 }
 
+// reconcileSGRules creates the smaller TemplateSGRules CFN stack that
+// layers cluster<->node ingress rules onto a pre-existing,
+// user-owned security group, skipping creation if that stack already
+// exists. The security group itself is never created or deleted by
+// aws-k8s-tester; only this rules stack is.
+func (ts *tester) reconcileSGRules(sgID string) error {
+	if ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID != "" {
+		ts.cfg.Logger.Info("node group security group rules already created; skipping",
+			zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID),
+		)
+		return nil
+	}
+
+	ts.cfg.Logger.Info("reconciling node group security group rules", zap.String("security-group-id", sgID))
+	stackInput := &cloudformation.CreateStackInput{
+		StackName:    aws.String(ts.cfg.EKSConfig.Name + "-ng-sg-rules"),
+		OnFailure:    aws.String(cloudformation.OnFailureDelete),
+		TemplateBody: aws.String(TemplateSGRules),
+		Tags: awscfn.NewTags(map[string]string{
+			"Kind":                   "aws-k8s-tester",
+			"Name":                   ts.cfg.EKSConfig.Name,
+			"aws-k8s-tester-version": version.ReleaseVersion,
+		}),
+		Parameters: []*cloudformation.Parameter{
+			{
+				ParameterKey:   aws.String("ControlPlaneSecurityGroupID"),
+				ParameterValue: aws.String(ts.cfg.EKSConfig.Parameters.ControlPlaneSecurityGroupID),
+			},
+			{
+				ParameterKey:   aws.String("NodeSecurityGroupID"),
+				ParameterValue: aws.String(sgID),
+			},
+		},
+	}
+	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID = aws.StringValue(stackOutput.StackId)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.EKSConfig.AddOnNodeGroups.SGCreateTimeout)
+	ch := awscfn.Poll(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Sig,
+		ts.cfg.Logger,
+		ts.cfg.CFNAPI,
+		ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID,
+		cloudformation.ResourceStatusCreateComplete,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInterval,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInitialWait,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			cancel()
+			ts.cfg.EKSConfig.RecordStatus(fmt.Sprintf("failed to reconcile node group security group rules (%v)", st.Error))
+			return st.Error
+		}
+	}
+	cancel()
+
+	ts.cfg.Logger.Info("reconciled node group security group rules",
+		zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID),
+	)
+	return ts.cfg.EKSConfig.Sync()
+}
+
+func (ts *tester) deleteSGRules() error {
+	if ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting node group security group rules CFN stack",
+		zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID),
+	)
+	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+		StackName: aws.String(ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID),
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.EKSConfig.AddOnNodeGroups.SGDeleteTimeout)
+	ch := awscfn.Poll(
+		ctx,
+		make(chan struct{}),  // do not exit on stop
+		make(chan os.Signal), // do not exit on stop
+		ts.cfg.Logger,
+		ts.cfg.CFNAPI,
+		ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupRulesCFNStackID,
+		cloudformation.ResourceStatusDeleteComplete,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInterval,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInitialWait,
+	)
+	var st awscfn.StackStatus
+	for st = range ch {
+		if st.Error != nil {
+			cancel()
+			ts.cfg.EKSConfig.RecordStatus(fmt.Sprintf("failed to delete node group security group rules (%v)", st.Error))
+			return st.Error
+		}
+	}
+	cancel()
+	ts.cfg.Logger.Info("deleted node group security group rules CFN stack")
+	return ts.cfg.EKSConfig.Sync()
+}
+
 func (ts *tester) deleteSG() error {
+	if !ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCreate {
+		if err := ts.deleteSGRules(); err != nil {
+			return err
+		}
+		ts.cfg.Logger.Info("NodeGroupSecurityGroupCreate false; no need to delete shared node group security group")
+		return nil
+	}
 	if ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCFNStackID == "" {
 		ts.cfg.Logger.Info("empty node group security group CFN stack ID; no need to delete node group")
 		return nil
@@ -240,7 +614,7 @@ func (ts *tester) deleteSG() error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.EKSConfig.AddOnNodeGroups.SGDeleteTimeout)
 	ch := awscfn.Poll(
 		ctx,
 		make(chan struct{}),  // do not exit on stop
@@ -249,8 +623,8 @@ func (ts *tester) deleteSG() error {
 		ts.cfg.CFNAPI,
 		ts.cfg.EKSConfig.AddOnNodeGroups.NodeGroupSecurityGroupCFNStackID,
 		cloudformation.ResourceStatusDeleteComplete,
-		time.Minute,
-		10*time.Second,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInterval,
+		ts.cfg.EKSConfig.AddOnNodeGroups.SGPollInitialWait,
 	)
 	var st awscfn.StackStatus
 	for st = range ch {
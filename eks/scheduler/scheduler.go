@@ -0,0 +1,69 @@
+// Package scheduler sequences add-on Tester.Create calls so each add-on's
+// Dependencies() finish Create before it starts -- e.g. Fargate before node
+// groups, so CoreDNS never gets scheduled onto an EC2 node a node group
+// rollout is about to replace.
+//
+// The top-level EKS deployer that would call Sequence isn't present in this
+// snapshot (eks/ has no file exposing a node-group Tester with a
+// Dependencies() method), so nothing in this tree invokes Sequence yet.
+// It's ready for that deployer to call once it exists: pass every enabled
+// add-on's Tester keyed by its own exported Name constant (e.g.
+// fargate.Name), and Create each name Sequence returns, in order.
+package scheduler
+
+import "fmt"
+
+// Tester is the subset of an add-on tester a Scheduler needs: its own
+// Create, and the names of add-ons that must finish Create before this one
+// starts (by their exported Name constant, e.g. fargate.Name).
+type Tester interface {
+	Create() error
+	Dependencies() []string
+}
+
+// Sequence topologically sorts testers by Dependencies(), so a caller can
+// Create each returned name in order instead of hand-rolling the ordering
+// itself. It returns an error if a dependency name isn't present in
+// testers, or if testers has a dependency cycle.
+func Sequence(testers map[string]Tester) ([]string, error) {
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[string]int, len(testers))
+	order := make([]string, 0, len(testers))
+
+	var visit func(name string, from string) error
+	visit = func(name string, from string) error {
+		switch color[name] {
+		case black:
+			return nil
+		case gray:
+			return fmt.Errorf("dependency cycle detected at %q", name)
+		}
+		t, ok := testers[name]
+		if !ok {
+			if from == "" {
+				return fmt.Errorf("%q was not passed to Sequence", name)
+			}
+			return fmt.Errorf("%q depends on %q, which was not passed to Sequence", from, name)
+		}
+		color[name] = gray
+		for _, dep := range t.Dependencies() {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+		}
+		color[name] = black
+		order = append(order, name)
+		return nil
+	}
+
+	for name := range testers {
+		if err := visit(name, ""); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
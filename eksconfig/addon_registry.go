@@ -0,0 +1,122 @@
+package eksconfig
+
+// This file registers the add-ons that predate the AddOn/RegisterAddOn
+// registry (see env.go) with UpdateFromEnvs, so they're parsed by the
+// generic registry loop instead of their own hand-written block. Each
+// add-on's own config-only checks are minimal (nil), since the checks that
+// used to live implicitly in those blocks were Config-dependent and already
+// live on Config's own validateAddOnX methods in validate-defaults.go.
+func init() {
+	RegisterAddOn("AddOnNodeGroups", func() AddOn { return &AddOnNodeGroups{} })
+	RegisterAddOn("AddOnManagedNodeGroups", func() AddOn { return &AddOnManagedNodeGroups{} })
+	RegisterAddOn("AddOnNLBHelloWorld", func() AddOn { return &AddOnNLBHelloWorld{} })
+	RegisterAddOn("AddOnALB2048", func() AddOn { return &AddOnALB2048{} })
+	RegisterAddOn("AddOnJobPi", func() AddOn { return &AddOnJobPi{} })
+	RegisterAddOn("AddOnJobEcho", func() AddOn { return &AddOnJobEcho{} })
+	RegisterAddOn("AddOnCronJob", func() AddOn { return &AddOnCronJob{} })
+	RegisterAddOn("AddOnSecrets", func() AddOn { return &AddOnSecrets{} })
+	RegisterAddOn("AddOnIRSA", func() AddOn { return &AddOnIRSA{} })
+	RegisterAddOn("AddOnAppMesh", func() AddOn { return &AddOnAppMesh{} })
+}
+
+// EnvPrefix implements AddOn.
+func (a *AddOnNodeGroups) EnvPrefix() string { return EnvironmentVariablePrefixAddOnNodeGroups }
+
+// Default implements AddOn by resetting AddOnNodeGroups to its zero value.
+func (a *AddOnNodeGroups) Default() { *a = AddOnNodeGroups{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnNodeGroups.
+func (a *AddOnNodeGroups) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnManagedNodeGroups) EnvPrefix() string {
+	return EnvironmentVariablePrefixAddOnManagedNodeGroups
+}
+
+// Default implements AddOn by resetting AddOnManagedNodeGroups to its zero value.
+func (a *AddOnManagedNodeGroups) Default() { *a = AddOnManagedNodeGroups{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnManagedNodeGroups.
+func (a *AddOnManagedNodeGroups) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnNLBHelloWorld) EnvPrefix() string { return EnvironmentVariablePrefixAddOnNLBHelloWorld }
+
+// Default implements AddOn by resetting AddOnNLBHelloWorld to its zero value.
+func (a *AddOnNLBHelloWorld) Default() { *a = AddOnNLBHelloWorld{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnNLBHelloWorld.
+func (a *AddOnNLBHelloWorld) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnALB2048) EnvPrefix() string { return EnvironmentVariablePrefixAddOnALB2048 }
+
+// Default implements AddOn by resetting AddOnALB2048 to its zero value.
+func (a *AddOnALB2048) Default() { *a = AddOnALB2048{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnALB2048.
+func (a *AddOnALB2048) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnJobPi) EnvPrefix() string { return EnvironmentVariablePrefixAddOnJobPi }
+
+// Default implements AddOn by resetting AddOnJobPi to its zero value.
+func (a *AddOnJobPi) Default() { *a = AddOnJobPi{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnJobPi.
+func (a *AddOnJobPi) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnJobEcho) EnvPrefix() string { return EnvironmentVariablePrefixAddOnJobEcho }
+
+// Default implements AddOn by resetting AddOnJobEcho to its zero value.
+func (a *AddOnJobEcho) Default() { *a = AddOnJobEcho{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnJobEcho.
+func (a *AddOnJobEcho) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnCronJob) EnvPrefix() string { return EnvironmentVariablePrefixAddOnCronJob }
+
+// Default implements AddOn by resetting AddOnCronJob to its zero value.
+func (a *AddOnCronJob) Default() { *a = AddOnCronJob{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnCronJob.
+func (a *AddOnCronJob) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnSecrets) EnvPrefix() string { return EnvironmentVariablePrefixAddOnSecrets }
+
+// Default implements AddOn by resetting AddOnSecrets to its zero value.
+func (a *AddOnSecrets) Default() { *a = AddOnSecrets{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnSecrets.
+func (a *AddOnSecrets) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnIRSA) EnvPrefix() string { return EnvironmentVariablePrefixAddOnIRSA }
+
+// Default implements AddOn by resetting AddOnIRSA to its zero value.
+func (a *AddOnIRSA) Default() { *a = AddOnIRSA{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnIRSA.
+func (a *AddOnIRSA) Validate() error { return nil }
+
+// EnvPrefix implements AddOn.
+func (a *AddOnAppMesh) EnvPrefix() string { return EnvironmentVariablePrefixAddOnAppMesh }
+
+// Default implements AddOn by resetting AddOnAppMesh to its zero value.
+func (a *AddOnAppMesh) Default() { *a = AddOnAppMesh{} }
+
+// Validate implements AddOn. Config-dependent checks live on
+// Config.validateAddOnAppMesh.
+func (a *AddOnAppMesh) Validate() error { return nil }
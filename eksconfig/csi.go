@@ -0,0 +1,73 @@
+package eksconfig
+
+import "time"
+
+func init() {
+	RegisterAddOn("AddOnCSI", func() AddOn { return &AddOnCSI{} })
+}
+
+// EnvPrefix implements AddOn.
+func (a *AddOnCSI) EnvPrefix() string { return EnvironmentVariablePrefixAddOnCSI }
+
+// Default implements AddOn by resetting AddOnCSI to its zero value.
+// Defaults that need the rest of Config (e.g. Namespace/ResultPath derived
+// from Config.Name) are still applied by Config.validateAddOnCSI.
+func (a *AddOnCSI) Default() { *a = AddOnCSI{} }
+
+// Validate implements AddOn, checking only what AddOnCSI can verify on its
+// own; Config.validateAddOnCSI covers everything that depends on the rest
+// of Config (driver support, IRSA requirement, cost cap).
+func (a *AddOnCSI) Validate() error { return nil }
+
+// AddOnCSI deploys a CSI driver and runs a StatefulSet write/read
+// conformance suite against a StorageClass backed by it, recording
+// per-replica latencies to a CSV report at ResultPath.
+type AddOnCSI struct {
+	// Enable deploys the CSI driver and runs the conformance suite.
+	Enable bool `json:"enable"`
+
+	// Driver selects the CSI driver to test. Only "ebs" is implemented; see
+	// validDrivers in validate-defaults.go.
+	Driver string `json:"driver"`
+
+	// Namespace is where the StatefulSet and its PVCs are created. Defaults
+	// to "<Config.Name>-csi".
+	Namespace string `json:"namespace"`
+
+	// StorageClassName is the StorageClass the StatefulSet's PVCs request.
+	// Defaults based on Driver (e.g. "gp3" for "ebs").
+	StorageClassName string `json:"storage-class-name"`
+
+	// StatefulSetName is the name of the conformance-suite StatefulSet.
+	// Defaults to "<Config.Name>-csi-<Driver>".
+	StatefulSetName string `json:"stateful-set-name"`
+
+	// StorageSize is the PVC storage request, e.g. "10Gi".
+	StorageSize string `json:"storage-size"`
+
+	// Replicas is the number of StatefulSet replicas, each with its own PVC.
+	// Defaults to 3.
+	Replicas int `json:"replicas"`
+
+	// WriteSize is how many bytes each replica writes and reads back per
+	// conformance-suite pass. Defaults to 10 MB. Replicas*WriteSize is capped
+	// at maxTotalWriteSize.
+	WriteSize int `json:"write-size"`
+
+	// ResultPath is where the per-replica latency CSV report is written.
+	// Defaults to "<Config.Name>-csi-<Driver>-latencies.csv" next to
+	// ConfigPath.
+	ResultPath string `json:"result-path"`
+
+	// Created is true once Create has successfully deployed the CSI driver
+	// and StatefulSet.
+	Created bool `json:"created" read-only:"true"`
+	// CreateTook is how long the last Create call took.
+	CreateTook time.Duration `json:"create-took" read-only:"true"`
+	// CreateTookString is CreateTook.String(), for readable JSON output.
+	CreateTookString string `json:"create-took-string" read-only:"true"`
+	// DeleteTook is how long the last Delete call took.
+	DeleteTook time.Duration `json:"delete-took" read-only:"true"`
+	// DeleteTookString is DeleteTook.String(), for readable JSON output.
+	DeleteTookString string `json:"delete-took-string" read-only:"true"`
+}
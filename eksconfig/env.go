@@ -7,8 +7,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
-
-	"github.com/aws/aws-k8s-tester/ec2config"
+	"time"
 )
 
 const (
@@ -38,12 +37,50 @@ const (
 	EnvironmentVariablePrefixAddOnFargate = "AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_"
 	// EnvironmentVariablePrefixAddOnAppMesh is the environment variable prefix used for "eksconfig".
 	EnvironmentVariablePrefixAddOnAppMesh = "AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_"
+	// EnvironmentVariablePrefixAddOnGatekeeper is the environment variable prefix used for "eksconfig".
+	EnvironmentVariablePrefixAddOnGatekeeper = "AWS_K8S_TESTER_EKS_ADD_ON_GATEKEEPER_"
+	// EnvironmentVariablePrefixAddOnCSI is the environment variable prefix used for "eksconfig".
+	EnvironmentVariablePrefixAddOnCSI = "AWS_K8S_TESTER_EKS_ADD_ON_CSI_"
 )
 
+// AddOn is implemented by an add-on's config struct so UpdateFromEnvs can
+// discover, default, parse, and validate it generically instead of growing
+// another hand-written parse/assign block in UpdateFromEnvs every time an
+// add-on is added.
+type AddOn interface {
+	// EnvPrefix returns the environment variable prefix parseEnvs should
+	// read this add-on's fields under (e.g. EnvironmentVariablePrefixAddOnFargate).
+	EnvPrefix() string
+	// Default resets the add-on to its zero-value baseline. Defaults that
+	// depend on the rest of Config (e.g. a name derived from Config.Name)
+	// are still applied by Config's own validateAddOnX methods, since an
+	// AddOn has no access to the Config it's attached to.
+	Default()
+	// Validate checks the add-on's fields in isolation, once populated from
+	// file or env. Config-dependent checks still live on Config itself.
+	Validate() error
+}
+
+// addOnFactories holds one constructor per add-on registered with
+// RegisterAddOn, keyed by the name of the Config field that holds it (e.g.
+// "AddOnFargate" for Config.AddOnFargate).
+var addOnFactories = map[string]func() AddOn{}
+
+// RegisterAddOn makes an add-on's config available to UpdateFromEnvs under
+// name, which must match the corresponding Config field's name exactly.
+// Call this from an add-on's own init, so a package outside eksconfig can
+// contribute an add-on without eksconfig needing to import it.
+func RegisterAddOn(name string, factory func() AddOn) {
+	addOnFactories[name] = factory
+}
+
 // UpdateFromEnvs updates fields from environmental variables.
 // Empty values are ignored and do not overwrite fields with empty values.
 // WARNING: The environmetal variable value always overwrites current field
 // values if there's a conflict.
+// Every add-on is parsed even if an earlier one failed, so a caller with
+// several bad env vars sees all of them in the returned ValidationErrors
+// instead of fixing them one at a time.
 func (cfg *Config) UpdateFromEnvs() (err error) {
 	cfg.mu.Lock()
 	defer func() {
@@ -51,270 +88,255 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 		cfg.mu.Unlock()
 	}()
 
+	var errs ValidationErrors
+
 	var vv interface{}
-	vv, err = parseEnvs(EnvironmentVariablePrefix, cfg)
-	if err != nil {
-		return err
-	}
+	vv, verrs := parseEnvs(EnvironmentVariablePrefix, "Config", cfg)
+	errs = append(errs, verrs...)
 	if av, ok := vv.(*Config); ok {
 		cfg = av
 	} else {
-		return fmt.Errorf("expected *Config, got %T", vv)
+		errs = append(errs, ValidationError{AddOn: "Config", Cause: fmt.Errorf("expected *Config, got %T", vv)})
 	}
 
-	vv, err = parseEnvs(EnvironmentVariablePrefixParameters, cfg.Parameters)
-	if err != nil {
-		return err
-	}
+	vv, verrs = parseEnvs(EnvironmentVariablePrefixParameters, "Parameters", cfg.Parameters)
+	errs = append(errs, verrs...)
 	if av, ok := vv.(*Parameters); ok {
 		cfg.Parameters = av
 	} else {
-		return fmt.Errorf("expected *Parameters, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnNodeGroups, cfg.AddOnNodeGroups)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnNodeGroups); ok {
-		cfg.AddOnNodeGroups = av
-	} else {
-		return fmt.Errorf("expected *AddOnNodeGroups, got %T", vv)
+		errs = append(errs, ValidationError{AddOn: "Parameters", Cause: fmt.Errorf("expected *Parameters, got %T", vv)})
 	}
 
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnManagedNodeGroups, cfg.AddOnManagedNodeGroups)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnManagedNodeGroups); ok {
-		cfg.AddOnManagedNodeGroups = av
-	} else {
-		return fmt.Errorf("expected *AddOnManagedNodeGroups, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnNLBHelloWorld, cfg.AddOnNLBHelloWorld)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnNLBHelloWorld); ok {
-		cfg.AddOnNLBHelloWorld = av
-	} else {
-		return fmt.Errorf("expected *AddOnNLBHelloWorld, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnALB2048, cfg.AddOnALB2048)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnALB2048); ok {
-		cfg.AddOnALB2048 = av
-	} else {
-		return fmt.Errorf("expected *AddOnALB2048, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnJobPi, cfg.AddOnJobPi)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnJobPi); ok {
-		cfg.AddOnJobPi = av
-	} else {
-		return fmt.Errorf("expected *AddOnJobPi, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnJobEcho, cfg.AddOnJobEcho)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnJobEcho); ok {
-		cfg.AddOnJobEcho = av
-	} else {
-		return fmt.Errorf("expected *AddOnJobEcho, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCronJob, cfg.AddOnCronJob)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnCronJob); ok {
-		cfg.AddOnCronJob = av
-	} else {
-		return fmt.Errorf("expected *AddOnCronJob, got %T", vv)
-	}
-
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnSecrets, cfg.AddOnSecrets)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnSecrets); ok {
-		cfg.AddOnSecrets = av
-	} else {
-		return fmt.Errorf("expected *AddOnSecrets, got %T", vv)
+	// Every add-on below is registered with RegisterAddOn (see
+	// addon_registry.go for the legacy add-ons, and each add-on's own file,
+	// e.g. fargate.go, for the rest) and parsed generically here instead of
+	// each needing its own hand-written block.
+	cv := reflect.ValueOf(cfg).Elem()
+	for name, factory := range addOnFactories {
+		field := cv.FieldByName(name)
+		if !field.IsValid() {
+			errs = append(errs, ValidationError{AddOn: name, Cause: fmt.Errorf("registered but Config has no matching field")})
+			continue
+		}
+		addOn, ok := field.Interface().(AddOn)
+		if !ok || addOn == nil || field.IsNil() {
+			addOn = factory()
+		}
+		vv, verrs = parseEnvs(addOn.EnvPrefix(), name, addOn)
+		errs = append(errs, verrs...)
+		av, ok := vv.(AddOn)
+		if !ok {
+			errs = append(errs, ValidationError{AddOn: name, Cause: fmt.Errorf("expected AddOn, got %T", vv)})
+			continue
+		}
+		field.Set(reflect.ValueOf(av))
 	}
 
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnIRSA, cfg.AddOnIRSA)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnIRSA); ok {
-		cfg.AddOnIRSA = av
-	} else {
-		return fmt.Errorf("expected *AddOnIRSA, got %T", vv)
+	if len(errs) > 0 {
+		return errs
 	}
+	return nil
+}
 
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnFargate, cfg.AddOnFargate)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnFargate); ok {
-		cfg.AddOnFargate = av
-	} else {
-		return fmt.Errorf("expected *AddOnFargate, got %T", vv)
-	}
+var durationType = reflect.TypeOf(time.Duration(0))
 
-	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnAppMesh, cfg.AddOnAppMesh)
-	if err != nil {
-		return err
-	}
-	if av, ok := vv.(*AddOnAppMesh); ok {
-		cfg.AddOnAppMesh = av
-	} else {
-		return fmt.Errorf("expected *AddOnAppMesh, got %T", vv)
-	}
-	return nil
+// parseEnvs parses addOnName's env vars into addOn, returning every field
+// that failed to parse instead of stopping at the first one, so a caller
+// configuring several add-ons at once sees all of its mistakes together.
+func parseEnvs(pfx, addOnName string, addOn interface{}) (interface{}, ValidationErrors) {
+	errs := parseEnvsInto(pfx, addOnName, "", reflect.ValueOf(addOn).Elem())
+	return addOn, errs
 }
 
-func parseEnvs(pfx string, addOn interface{}) (interface{}, error) {
-	tp, vv := reflect.TypeOf(addOn).Elem(), reflect.ValueOf(addOn).Elem()
+// parseEnvsInto sets each field of vv from pfx+FIELD_JSON_TAG, recursing
+// into nested struct fields under pfx+FIELD_ so an add-on can grow nested
+// config without parseEnvs needing a new special case for every level.
+// jsonPath accumulates the dotted path to vv (e.g. "irsa") for error
+// context; a field that fails is recorded as a ValidationError and parsing
+// continues with the next field rather than returning immediately.
+func parseEnvsInto(pfx, addOnName, jsonPath string, vv reflect.Value) ValidationErrors {
+	var errs ValidationErrors
+	tp := vv.Type()
 	for i := 0; i < tp.NumField(); i++ {
-		jv := tp.Field(i).Tag.Get("json")
-		if jv == "" {
+		field := tp.Field(i)
+		tagName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tagName == "" {
 			continue
 		}
-		jv = strings.Replace(jv, ",omitempty", "", -1)
-		jv = strings.ToUpper(strings.Replace(jv, "-", "_", -1))
-		env := pfx + jv
+		env := pfx + strings.ToUpper(strings.Replace(tagName, "-", "_", -1))
+		path := tagName
+		if jsonPath != "" {
+			path = jsonPath + "." + tagName
+		}
+		fv := vv.Field(i)
+
+		if field.Tag.Get("read-only") == "true" { // skip updating read-only field
+			continue
+		}
+
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			errs = append(errs, parseEnvsInto(env+"_", addOnName, path, fv)...)
+			continue
+		}
+
 		sv := os.Getenv(env)
 		if sv == "" {
 			continue
 		}
-		if tp.Field(i).Tag.Get("read-only") == "true" { // skip updating read-only field
+
+		fail := func(cause error) {
+			errs = append(errs, ValidationError{AddOn: addOnName, EnvKey: env, JSONPath: path, RawValue: sv, Cause: cause})
+		}
+
+		if fv.Type() == durationType {
+			dv, err := time.ParseDuration(sv)
+			if err != nil {
+				fail(fmt.Errorf("not a valid duration (%v)", err))
+				continue
+			}
+			fv.SetInt(int64(dv))
 			continue
 		}
-		fieldName := tp.Field(i).Name
 
-		switch vv.Field(i).Type().Kind() {
+		switch fv.Kind() {
 		case reflect.String:
-			vv.Field(i).SetString(sv)
+			fv.SetString(sv)
 
 		case reflect.Bool:
 			bb, err := strconv.ParseBool(sv)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				fail(err)
+				continue
 			}
-			vv.Field(i).SetBool(bb)
+			fv.SetBool(bb)
 
 		case reflect.Int, reflect.Int32, reflect.Int64:
 			iv, err := strconv.ParseInt(sv, 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				fail(err)
+				continue
 			}
-			vv.Field(i).SetInt(iv)
+			fv.SetInt(iv)
 
 		case reflect.Uint, reflect.Uint32, reflect.Uint64:
 			iv, err := strconv.ParseUint(sv, 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				fail(err)
+				continue
 			}
-			vv.Field(i).SetUint(iv)
+			fv.SetUint(iv)
 
 		case reflect.Float32, reflect.Float64:
-			fv, err := strconv.ParseFloat(sv, 64)
+			fval, err := strconv.ParseFloat(sv, 64)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
-			}
-			vv.Field(i).SetFloat(fv)
-
-		case reflect.Slice: // only supports "[]string" for now
-			ss := strings.Split(sv, ",")
-			if len(ss) < 1 {
+				fail(err)
 				continue
 			}
-			slice := reflect.MakeSlice(reflect.TypeOf([]string{}), len(ss), len(ss))
-			for j := range ss {
-				slice.Index(j).SetString(ss[j])
+			fv.SetFloat(fval)
+
+		case reflect.Slice:
+			if err := parseEnvsSlice(fv, sv); err != nil {
+				fail(err)
 			}
-			vv.Field(i).Set(slice)
 
 		case reflect.Map:
-			switch fieldName {
-			case "Tags":
-				vv.Field(i).Set(reflect.ValueOf(make(map[string]string)))
-				for _, pair := range strings.Split(sv, ";") {
-					fields := strings.Split(pair, "=")
-					if len(fields) != 2 {
-						return nil, fmt.Errorf("map %q for %q has unexpected format (e.g. should be 'a=b;c;d,e=f')", sv, fieldName)
-					}
-					vv.Field(i).SetMapIndex(reflect.ValueOf(fields[0]), reflect.ValueOf(fields[1]))
-				}
+			if err := parseEnvsMap(fv, sv); err != nil {
+				fail(err)
+			}
 
-			case "ASGs":
-				asgs := make(map[string]ec2config.ASG)
-				if err := json.Unmarshal([]byte(sv), &asgs); err != nil {
-					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
-				}
-				for k, v := range asgs {
-					tp2, vv2 := reflect.TypeOf(&v).Elem(), reflect.ValueOf(&v).Elem()
-					for j := 0; j < tp2.NumField(); j++ {
-						jv := tp2.Field(j).Tag.Get("json")
-						if jv == "" {
-							continue
-						}
-						if tp2.Field(j).Tag.Get("read-only") != "true" {
-							continue
-						}
-						if vv2.Field(j).Type().Kind() != reflect.String {
-							continue
-						}
-						// skip updating read-only field
-						vv2.Field(j).SetString("")
-					}
-					asgs[k] = v
-				}
-				vv.Field(i).Set(reflect.ValueOf(asgs))
+		default:
+			fail(fmt.Errorf("type %v is not supported as an env", fv.Type()))
+		}
+	}
+	return errs
+}
 
-			case "MNGs":
-				mngs := make(map[string]MNG)
-				if err := json.Unmarshal([]byte(sv), &mngs); err != nil {
-					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
-				}
-				for k, v := range mngs {
-					tp2, vv2 := reflect.TypeOf(&v).Elem(), reflect.ValueOf(&v).Elem()
-					for j := 0; j < tp2.NumField(); j++ {
-						jv := tp2.Field(j).Tag.Get("json")
-						if jv == "" {
-							continue
-						}
-						if tp2.Field(j).Tag.Get("read-only") != "true" {
-							continue
-						}
-						if vv2.Field(j).Type().Kind() != reflect.String {
-							continue
-						}
-						// skip updating read-only field
-						vv2.Field(j).SetString("")
-					}
-					mngs[k] = v
-				}
-				vv.Field(i).Set(reflect.ValueOf(mngs))
+// parseEnvsSlice sets fv (a slice field) from sv: a comma-separated list
+// for []string (unchanged, for backward compatibility), or a JSON array
+// (sv starting with "[") for anything else, including []int/[]bool/
+// []float64 and slices of struct, e.g. []FargateProfileSpec.
+func parseEnvsSlice(fv reflect.Value, sv string) error {
+	st := fv.Type()
+	if st.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(sv), "[") {
+		ss := strings.Split(sv, ",")
+		slice := reflect.MakeSlice(st, len(ss), len(ss))
+		for j := range ss {
+			slice.Index(j).SetString(ss[j])
+		}
+		fv.Set(slice)
+		return nil
+	}
+	if !strings.HasPrefix(strings.TrimSpace(sv), "[") {
+		return fmt.Errorf("expects a JSON array")
+	}
+	decodedPtr := reflect.New(st)
+	if err := json.Unmarshal([]byte(sv), decodedPtr.Interface()); err != nil {
+		return fmt.Errorf("not a valid JSON array (%v)", err)
+	}
+	decoded := decodedPtr.Elem()
+	if st.Elem().Kind() == reflect.Struct {
+		for j := 0; j < decoded.Len(); j++ {
+			stripReadOnly(decoded.Index(j))
+		}
+	}
+	fv.Set(decoded)
+	return nil
+}
 
-			default:
-				return nil, fmt.Errorf("field %q not supported for reflect.Map", fieldName)
+// parseEnvsMap sets fv (a map field) from sv: the existing "a=b;c=d"
+// shorthand for map[string]string (e.g. Tags), or a JSON object (sv
+// starting with "{") for anything else, including map[string]ec2config.ASG
+// and map[string]MNG, which previously needed their own cases here.
+func parseEnvsMap(fv reflect.Value, sv string) error {
+	mt := fv.Type()
+	if mt.Key().Kind() == reflect.String && mt.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(sv), "{") {
+		m := reflect.MakeMap(mt)
+		for _, pair := range strings.Split(sv, ";") {
+			fields := strings.Split(pair, "=")
+			if len(fields) != 2 {
+				return fmt.Errorf("unexpected format (expected 'a=b;c=d')")
 			}
+			m.SetMapIndex(reflect.ValueOf(fields[0]), reflect.ValueOf(fields[1]))
+		}
+		fv.Set(m)
+		return nil
+	}
+	if !strings.HasPrefix(strings.TrimSpace(sv), "{") {
+		return fmt.Errorf("expects a JSON object")
+	}
+	decodedPtr := reflect.New(mt)
+	if err := json.Unmarshal([]byte(sv), decodedPtr.Interface()); err != nil {
+		return fmt.Errorf("not a valid JSON object (%v)", err)
+	}
+	decoded := decodedPtr.Elem()
+	if mt.Elem().Kind() == reflect.Struct {
+		for _, k := range decoded.MapKeys() {
+			ev := reflect.New(mt.Elem()).Elem()
+			ev.Set(decoded.MapIndex(k))
+			stripReadOnly(ev)
+			decoded.SetMapIndex(k, ev)
+		}
+	}
+	fv.Set(decoded)
+	return nil
+}
 
-		default:
-			return nil, fmt.Errorf("%q (type %v) is not supported as an env", env, vv.Field(i).Type())
+// stripReadOnly blanks every string field tagged read-only:"true" on v, so
+// a map/slice entry decoded from JSON can't smuggle in a value that's only
+// meant to be set by the tester itself (e.g. a CFN stack ID). Previously
+// only ASGs/MNGs got this treatment; now any struct field does.
+func stripReadOnly(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("read-only") != "true" {
+			continue
+		}
+		if v.Field(i).Kind() != reflect.String {
+			continue
 		}
+		v.Field(i).SetString("")
 	}
-	return addOn, nil
 }
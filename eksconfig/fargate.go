@@ -0,0 +1,72 @@
+package eksconfig
+
+import "errors"
+
+func init() {
+	RegisterAddOn("AddOnFargate", func() AddOn { return &AddOnFargate{} })
+}
+
+// EnvPrefix implements AddOn.
+func (a *AddOnFargate) EnvPrefix() string { return EnvironmentVariablePrefixAddOnFargate }
+
+// Default implements AddOn by resetting AddOnFargate to its zero value.
+// Defaults that need the rest of Config (e.g. Namespace/ProfileName derived
+// from Config.Name) are still applied by Config.validateAddOnFargate.
+func (a *AddOnFargate) Default() { *a = AddOnFargate{} }
+
+// Validate implements AddOn, checking only what AddOnFargate can verify on
+// its own; Config.validateAddOnFargate covers everything that depends on
+// the rest of Config.
+func (a *AddOnFargate) Validate() error {
+	for _, p := range a.Profiles {
+		if p.Name == "" {
+			return errors.New("AddOnFargate.Profiles entry has an empty Name")
+		}
+	}
+	return nil
+}
+
+// FargateProfileSpec defines a single additional AWS Fargate profile to
+// create alongside AddOnFargate's default profile, each with its own
+// subnets and namespace/label selectors. This lets a cluster host multiple
+// Fargate profiles with distinct subnets or selectors, e.g. for
+// multi-tenant test fleets. Every profile uses AddOnFargate.RoleARN as its
+// pod execution role; a spec has no role of its own.
+type FargateProfileSpec struct {
+	// Name is the Fargate profile name, passed as FargateProfileName.
+	Name string `json:"name"`
+	// Subnets overrides Parameters.PrivateSubnetIDs for this profile only.
+	// Falls back to Parameters.PrivateSubnetIDs when empty.
+	Subnets []string `json:"subnets"`
+	// Selectors are this profile's namespace/label selectors.
+	Selectors []FargateProfileSelectorSpec `json:"selectors"`
+}
+
+// FargateProfileSelectorSpec mirrors eks.FargateProfileSelector.
+type FargateProfileSelectorSpec struct {
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// AddOnFargateIRSA configures IAM Roles for Service Accounts (IRSA) for the
+// Fargate test Pod, so the Pod can assume an IAM role through its
+// ServiceAccount rather than inheriting permissions from the pod execution
+// role. RoleCFNStackID/RoleARN are populated by the tester and should not be
+// set by the caller.
+type AddOnFargateIRSA struct {
+	// Enable schedules the IRSA role, ServiceAccount, and verification
+	// container alongside the Fargate test Pod.
+	Enable bool `json:"enable"`
+
+	// RoleName is the name of the IAM role the ServiceAccount assumes.
+	// If empty, a name is derived from EKSConfig.Name.
+	RoleName string `json:"role-name"`
+	// ServiceAccountName is the name of the ServiceAccount annotated with
+	// the IRSA role ARN. If empty, a name is derived from EKSConfig.Name.
+	ServiceAccountName string `json:"service-account-name"`
+
+	// RoleCFNStackID is the CFN stack ID of the IRSA role.
+	RoleCFNStackID string `json:"role-cfn-stack-id" read-only:"true"`
+	// RoleARN is the ARN of the IRSA role.
+	RoleARN string `json:"role-arn" read-only:"true"`
+}
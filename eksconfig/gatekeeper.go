@@ -0,0 +1,57 @@
+package eksconfig
+
+import "time"
+
+func init() {
+	RegisterAddOn("AddOnGatekeeper", func() AddOn { return &AddOnGatekeeper{} })
+}
+
+// EnvPrefix implements AddOn.
+func (a *AddOnGatekeeper) EnvPrefix() string { return EnvironmentVariablePrefixAddOnGatekeeper }
+
+// Default implements AddOn by resetting AddOnGatekeeper to its zero value.
+// Defaults that need the rest of Config (e.g. Namespace/ResultPath derived
+// from Config.Name) are still applied by Config.validateAddOnGatekeeper.
+func (a *AddOnGatekeeper) Default() { *a = AddOnGatekeeper{} }
+
+// Validate implements AddOn, checking only what AddOnGatekeeper can verify
+// on its own; Config.validateAddOnGatekeeper covers everything that depends
+// on the rest of Config.
+func (a *AddOnGatekeeper) Validate() error { return nil }
+
+// AddOnGatekeeper installs OPA Gatekeeper and enforces one or more
+// ConstraintTemplate/Constraint pairs, reporting audit violations to a CSV
+// report at ResultPath.
+type AddOnGatekeeper struct {
+	// Enable installs OPA Gatekeeper and its constraints.
+	Enable bool `json:"enable"`
+
+	// Namespace is the namespace Gatekeeper's controller/audit Deployments
+	// run in. Defaults to "gatekeeper-system".
+	Namespace string `json:"namespace"`
+
+	// RequiredLabels is the built-in "required labels" demo policy's label
+	// list, used only when TemplateDir is empty. Defaults to ["team"].
+	RequiredLabels []string `json:"required-labels"`
+
+	// TemplateDir optionally points to a directory of user-supplied
+	// "<name>-template.yaml"/"<name>-constraint.yaml" pairs to apply instead
+	// of the built-in "required labels" demo policy.
+	TemplateDir string `json:"template-dir"`
+
+	// ResultPath is where the audit-controller violations CSV report is
+	// written. Defaults to "<Config.Name>-gatekeeper-violations.csv" next to
+	// ConfigPath.
+	ResultPath string `json:"result-path"`
+
+	// Created is true once Create has successfully installed Gatekeeper.
+	Created bool `json:"created" read-only:"true"`
+	// CreateTook is how long the last Create call took.
+	CreateTook time.Duration `json:"create-took" read-only:"true"`
+	// CreateTookString is CreateTook.String(), for readable JSON output.
+	CreateTookString string `json:"create-took-string" read-only:"true"`
+	// DeleteTook is how long the last Delete call took.
+	DeleteTook time.Duration `json:"delete-took" read-only:"true"`
+	// DeleteTookString is DeleteTook.String(), for readable JSON output.
+	DeleteTookString string `json:"delete-took-string" read-only:"true"`
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/aws"
+	"github.com/aws/aws-k8s-tester/pkg/aws/naming"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/logutil"
 	"github.com/aws/aws-sdk-go/service/eks"
@@ -52,6 +53,19 @@ var DefaultConfig = Config{
 
 	OnFailureDelete:            true,
 	OnFailureDeleteWaitSeconds: 120,
+	// OnFailureDeletePartial only tears down the resources recorded for the
+	// add-on whose create step failed, rather than the whole cluster.
+	OnFailureDeletePartial: false,
+
+	// FargateFirst requires a deployer to fully create AddOnFargate (profiles
+	// ACTIVE, CoreDNS rescheduled off EC2) before starting node group
+	// creation, so CoreDNS never gets scheduled onto an EC2 node that is
+	// about to disappear from under it. Set false to preserve the previous
+	// behavior of creating node groups and Fargate profiles independently.
+	// Read through Config.FargateMustPrecedeNodeGroups, which a deployer
+	// uses to decide whether to feed AddOnFargate into its
+	// eks/scheduler.Sequence call as a node group dependency.
+	FargateFirst: true,
 
 	S3BucketName:                    "",
 	S3BucketCreate:                  false,
@@ -92,6 +106,8 @@ var DefaultConfig = Config{
 		Enable:                 false,
 		DeploymentReplicasALB:  3,
 		DeploymentReplicas2048: 3,
+		RoleCreate:             true,
+		ControllerVersion:      "v1",
 	},
 
 	AddOnJobPi: &AddOnJobPi{
@@ -155,6 +171,18 @@ var DefaultConfig = Config{
 		Enable: false,
 	},
 
+	AddOnGatekeeper: &AddOnGatekeeper{
+		Enable: false,
+	},
+
+	AddOnCSI: &AddOnCSI{
+		Enable:      false,
+		Driver:      "ebs",
+		StorageSize: "10Gi",
+		Replicas:    3,
+		WriteSize:   10 * 1024 * 1024, // 10 MB
+	},
+
 	// read-only
 	Status: &Status{Up: false},
 }
@@ -167,7 +195,7 @@ func NewDefault() *Config {
 	if name := os.Getenv(EnvironmentVariablePrefix + "NAME"); name != "" {
 		vv.Name = name
 	} else {
-		vv.Name = fmt.Sprintf("eks-%s-%s", getTS()[:10], randString(12))
+		vv.Name = naming.Gen("eks-"+getTS()[:10], 12)
 	}
 
 	vv.AddOnNodeGroups.ASGs = map[string]ec2config.ASG{
@@ -210,7 +238,7 @@ func init() {
 
 	if runtime.GOOS == "darwin" {
 		DefaultConfig.KubectlDownloadURL = strings.Replace(DefaultConfig.KubectlDownloadURL, "linux", "darwin", -1)
-		DefaultConfig.RemoteAccessPrivateKeyPath = filepath.Join(os.TempDir(), randString(10)+".insecure.key")
+		DefaultConfig.RemoteAccessPrivateKeyPath = filepath.Join(os.TempDir(), naming.Rand(10)+".insecure.key")
 	}
 }
 
@@ -227,46 +255,38 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		cfg.mu.Unlock()
 	}()
 
-	if err := cfg.validateConfig(); err != nil {
-		return fmt.Errorf("validateConfig failed [%v]", err)
-	}
-	if err := cfg.validateParameters(); err != nil {
-		return fmt.Errorf("validateParameters failed [%v]", err)
-	}
-	if err := cfg.validateAddOnNodeGroups(); err != nil {
-		return fmt.Errorf("validateAddOnNodeGroups failed [%v]", err)
-	}
-	if err := cfg.validateAddOnManagedNodeGroups(); err != nil {
-		return fmt.Errorf("validateAddOnManagedNodeGroups failed [%v]", err)
-	}
-	if err := cfg.validateAddOnNLBHelloWorld(); err != nil {
-		return fmt.Errorf("validateAddOnNLBHelloWorld failed [%v]", err)
-	}
-	if err := cfg.validateAddOnALB2048(); err != nil {
-		return fmt.Errorf("validateAddOnALB2048 failed [%v]", err)
+	// errs accumulates every validateX failure instead of returning at the
+	// first one, so a caller with several misconfigured add-ons sees all of
+	// them in one pass. Note a later validator may still derive a secondary
+	// error from an earlier one's failure (e.g. validateAddOnFargate checks
+	// Parameters.VersionValue, which validateParameters sets) -- that's an
+	// accepted trade-off of not short-circuiting.
+	var errs ValidationErrors
+	check := func(addOn string, fn func() error) {
+		if err := fn(); err != nil {
+			errs = append(errs, ValidationError{AddOn: addOn, Cause: err})
+		}
+	}
+
+	check("Config", cfg.validateConfig)
+	check("Parameters", cfg.validateParameters)
+	check("AddOnNodeGroups", cfg.validateAddOnNodeGroups)
+	check("AddOnManagedNodeGroups", cfg.validateAddOnManagedNodeGroups)
+	check("AddOnNLBHelloWorld", cfg.validateAddOnNLBHelloWorld)
+	check("AddOnALB2048", cfg.validateAddOnALB2048)
+	check("AddOnJobPi", cfg.validateAddOnJobPi)
+	check("AddOnJobEcho", cfg.validateAddOnJobEcho)
+	check("AddOnCronJob", cfg.validateAddOnCronJob)
+	check("AddOnSecrets", cfg.validateAddOnSecrets)
+	check("AddOnIRSA", cfg.validateAddOnIRSA)
+	check("AddOnFargate", cfg.validateAddOnFargate)
+	check("AddOnAppMesh", cfg.validateAddOnAppMesh)
+	check("AddOnGatekeeper", cfg.validateAddOnGatekeeper)
+	check("AddOnCSI", cfg.validateAddOnCSI)
+
+	if len(errs) > 0 {
+		return errs
 	}
-	if err := cfg.validateAddOnJobPi(); err != nil {
-		return fmt.Errorf("validateAddOnJobPi failed [%v]", err)
-	}
-	if err := cfg.validateAddOnJobEcho(); err != nil {
-		return fmt.Errorf("validateAddOnJobEcho failed [%v]", err)
-	}
-	if err := cfg.validateAddOnCronJob(); err != nil {
-		return fmt.Errorf("validateAddOnCronJob failed [%v]", err)
-	}
-	if err := cfg.validateAddOnSecrets(); err != nil {
-		return fmt.Errorf("validateAddOnSecrets failed [%v]", err)
-	}
-	if err := cfg.validateAddOnIRSA(); err != nil {
-		return fmt.Errorf("validateAddOnIRSA failed [%v]", err)
-	}
-	if err := cfg.validateAddOnFargate(); err != nil {
-		return fmt.Errorf("validateAddOnFargate failed [%v]", err)
-	}
-	if err := cfg.validateAddOnAppMesh(); err != nil {
-		return fmt.Errorf("validateAddOnAppMesh failed [%v]", err)
-	}
-
 	return nil
 }
 
@@ -436,6 +456,27 @@ func (cfg *Config) validateParameters() error {
 		}
 	}
 
+	switch cfg.Parameters.ControlPlaneSecurityGroupCreate {
+	case true: // need create one, or already created
+		if len(cfg.Parameters.ControlPlaneSecurityGroupIDs) > 0 {
+			return fmt.Errorf("Parameters.ControlPlaneSecurityGroupCreate true; expect empty ControlPlaneSecurityGroupIDs but got %q", cfg.Parameters.ControlPlaneSecurityGroupIDs)
+		}
+	case false: // use existing ones
+		if len(cfg.Parameters.ControlPlaneSecurityGroupIDs) == 0 {
+			return errors.New("Parameters.ControlPlaneSecurityGroupCreate false; expect non-empty ControlPlaneSecurityGroupIDs")
+		}
+		for _, sgID := range cfg.Parameters.ControlPlaneSecurityGroupIDs {
+			if !strings.HasPrefix(sgID, "sg-") {
+				return fmt.Errorf("invalid security group ID %q in Parameters.ControlPlaneSecurityGroupIDs", sgID)
+			}
+		}
+	}
+	for _, sgID := range cfg.Parameters.ControlPlaneAdditionalSecurityGroupIDs {
+		if !strings.HasPrefix(sgID, "sg-") {
+			return fmt.Errorf("invalid security group ID %q in Parameters.ControlPlaneAdditionalSecurityGroupIDs", sgID)
+		}
+	}
+
 	switch {
 	case cfg.Parameters.VPCCIDR != "":
 		switch {
@@ -516,6 +557,58 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 		cfg.AddOnNodeGroups.LogsDir = filepath.Join(filepath.Dir(cfg.ConfigPath), cfg.Name+"-logs-ngs")
 	}
 
+	switch cfg.AddOnNodeGroups.NodeGroupSecurityGroupCreate {
+	case true: // need create one, or already created
+		if len(cfg.AddOnNodeGroups.NodeGroupSecurityGroupIDs) > 0 {
+			return fmt.Errorf("AddOnNodeGroups.NodeGroupSecurityGroupCreate true; expect empty NodeGroupSecurityGroupIDs but got %q", cfg.AddOnNodeGroups.NodeGroupSecurityGroupIDs)
+		}
+	case false: // use existing ones
+		if len(cfg.AddOnNodeGroups.NodeGroupSecurityGroupIDs) == 0 {
+			return errors.New("AddOnNodeGroups.NodeGroupSecurityGroupCreate false; expect non-empty NodeGroupSecurityGroupIDs")
+		}
+		for _, sgID := range cfg.AddOnNodeGroups.NodeGroupSecurityGroupIDs {
+			if !strings.HasPrefix(sgID, "sg-") {
+				return fmt.Errorf("invalid security group ID %q in AddOnNodeGroups.NodeGroupSecurityGroupIDs", sgID)
+			}
+		}
+	}
+	for _, sgID := range cfg.AddOnNodeGroups.NodeGroupAdditionalSecurityGroupIDs {
+		if !strings.HasPrefix(sgID, "sg-") {
+			return fmt.Errorf("invalid security group ID %q in AddOnNodeGroups.NodeGroupAdditionalSecurityGroupIDs", sgID)
+		}
+	}
+	if cfg.AddOnNodeGroups.NodeGroupSecurityGroupReconcileRules && cfg.AddOnNodeGroups.NodeGroupSecurityGroupCreate {
+		return errors.New("AddOnNodeGroups.NodeGroupSecurityGroupReconcileRules true requires NodeGroupSecurityGroupCreate false")
+	}
+
+	if cfg.AddOnNodeGroups.RemoteAccess.Public {
+		if len(cfg.AddOnNodeGroups.RemoteAccess.SourceCIDRs) == 0 && len(cfg.AddOnNodeGroups.RemoteAccess.SourceSecurityGroupIDs) == 0 {
+			return errors.New("AddOnNodeGroups.RemoteAccess.Public true; expect non-empty SourceCIDRs or SourceSecurityGroupIDs")
+		}
+	} else {
+		if len(cfg.AddOnNodeGroups.RemoteAccess.SourceCIDRs) > 0 || len(cfg.AddOnNodeGroups.RemoteAccess.SourceSecurityGroupIDs) > 0 {
+			return errors.New("AddOnNodeGroups.RemoteAccess.Public false; expect empty SourceCIDRs and SourceSecurityGroupIDs")
+		}
+	}
+	for _, sgID := range cfg.AddOnNodeGroups.RemoteAccess.SourceSecurityGroupIDs {
+		if !strings.HasPrefix(sgID, "sg-") {
+			return fmt.Errorf("invalid security group ID %q in AddOnNodeGroups.RemoteAccess.SourceSecurityGroupIDs", sgID)
+		}
+	}
+
+	if cfg.AddOnNodeGroups.SGCreateTimeout == 0 {
+		cfg.AddOnNodeGroups.SGCreateTimeout = 15 * time.Minute
+	}
+	if cfg.AddOnNodeGroups.SGDeleteTimeout == 0 {
+		cfg.AddOnNodeGroups.SGDeleteTimeout = 15 * time.Minute
+	}
+	if cfg.AddOnNodeGroups.SGPollInterval == 0 {
+		cfg.AddOnNodeGroups.SGPollInterval = time.Minute
+	}
+	if cfg.AddOnNodeGroups.SGPollInitialWait == 0 {
+		cfg.AddOnNodeGroups.SGPollInitialWait = 10 * time.Second
+	}
+
 	switch cfg.AddOnNodeGroups.RoleCreate {
 	case true: // need create one, or already created
 		if cfg.AddOnNodeGroups.RoleName == "" {
@@ -535,15 +628,20 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 				  NodegroupName: "test-ng-cpu"
 				})
 			*/
+			partition := GetPartition(cfg.Region)
+			reqPrincipal := RequiredServicePrincipal("ec2.amazonaws.com", partition)
 			found := false
 			for _, pv := range cfg.AddOnNodeGroups.RoleServicePrincipals {
-				if pv == "ec2.amazonaws.com" { // TODO: support China regions ec2.amazonaws.com.cn or eks.amazonaws.com.cn
+				if pv == reqPrincipal {
 					found = true
 					break
 				}
 			}
 			if !found {
-				return fmt.Errorf("AddOnNodeGroups.RoleServicePrincipals %q must include 'ec2.amazonaws.com'", cfg.AddOnNodeGroups.RoleServicePrincipals)
+				return fmt.Errorf("AddOnNodeGroups.RoleServicePrincipals %q must include %q", cfg.AddOnNodeGroups.RoleServicePrincipals, reqPrincipal)
+			}
+			if partition == "aws-us-gov" && cfg.AddOnNodeGroups.RoleARN != "" && !strings.Contains(cfg.AddOnNodeGroups.RoleARN, ":aws-us-gov:") {
+				return fmt.Errorf("Region %q is GovCloud but AddOnNodeGroups.RoleARN %q is not in the aws-us-gov partition", cfg.Region, cfg.AddOnNodeGroups.RoleARN)
 			}
 		}
 
@@ -577,6 +675,31 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 			return fmt.Errorf("AddOnNodeGroups.ASGs[%q].Name %q is redundant", k, v.Name)
 		}
 
+		if v.InstanceProfileARN != "" || v.InstanceProfileName != "" {
+			if v.InstanceProfileARN == "" || v.InstanceProfileName == "" {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] must set both InstanceProfileARN and InstanceProfileName, got ARN %q, Name %q", k, v.InstanceProfileARN, v.InstanceProfileName)
+			}
+			if cfg.AddOnNodeGroups.RoleCreate {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q].InstanceProfileARN %q conflicts with AddOnNodeGroups.RoleCreate true", k, v.InstanceProfileARN)
+			}
+		}
+
+		switch v.SecurityGroupCreate {
+		case true: // need create one, or already created
+			if len(v.SecurityGroupIDs) > 0 {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q].SecurityGroupCreate true; expect empty SecurityGroupIDs but got %q", k, v.SecurityGroupIDs)
+			}
+		case false: // use existing ones
+			if len(v.SecurityGroupIDs) == 0 {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q].SecurityGroupCreate false; expect non-empty SecurityGroupIDs", k)
+			}
+			for _, sgID := range v.SecurityGroupIDs {
+				if !strings.HasPrefix(sgID, "sg-") {
+					return fmt.Errorf("invalid security group ID %q in AddOnNodeGroups.ASGs[%q].SecurityGroupIDs", sgID, k)
+				}
+			}
+		}
+
 		if len(v.InstanceTypes) > 4 {
 			return fmt.Errorf("too many InstaceTypes[%q]", v.InstanceTypes)
 		}
@@ -719,15 +842,20 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 				  NodegroupName: "test-mng-cpu"
 				})
 			*/
+			partition := GetPartition(cfg.Region)
+			reqPrincipal := RequiredServicePrincipal("ec2.amazonaws.com", partition)
 			found := false
 			for _, pv := range cfg.AddOnManagedNodeGroups.RoleServicePrincipals {
-				if pv == "ec2.amazonaws.com" { // TODO: support China regions ec2.amazonaws.com.cn or eks.amazonaws.com.cn
+				if pv == reqPrincipal {
 					found = true
 					break
 				}
 			}
 			if !found {
-				return fmt.Errorf("AddOnManagedNodeGroups.RoleServicePrincipals %q must include 'ec2.amazonaws.com'", cfg.AddOnManagedNodeGroups.RoleServicePrincipals)
+				return fmt.Errorf("AddOnManagedNodeGroups.RoleServicePrincipals %q must include %q", cfg.AddOnManagedNodeGroups.RoleServicePrincipals, reqPrincipal)
+			}
+			if partition == "aws-us-gov" && cfg.AddOnManagedNodeGroups.RoleARN != "" && !strings.Contains(cfg.AddOnManagedNodeGroups.RoleARN, ":aws-us-gov:") {
+				return fmt.Errorf("Region %q is GovCloud but AddOnManagedNodeGroups.RoleARN %q is not in the aws-us-gov partition", cfg.Region, cfg.AddOnManagedNodeGroups.RoleARN)
 			}
 		}
 
@@ -767,6 +895,22 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 			}
 		}
 
+		switch v.SecurityGroupCreate {
+		case true: // need create one, or already created
+			if len(v.SecurityGroupIDs) > 0 {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q].SecurityGroupCreate true; expect empty SecurityGroupIDs but got %q", k, v.SecurityGroupIDs)
+			}
+		case false: // use existing ones
+			if len(v.SecurityGroupIDs) == 0 {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q].SecurityGroupCreate false; expect non-empty SecurityGroupIDs", k)
+			}
+			for _, sgID := range v.SecurityGroupIDs {
+				if !strings.HasPrefix(sgID, "sg-") {
+					return fmt.Errorf("invalid security group ID %q in AddOnManagedNodeGroups.MNGs[%q].SecurityGroupIDs", sgID, k)
+				}
+			}
+		}
+
 		if len(v.InstanceTypes) > 4 {
 			return fmt.Errorf("too many InstaceTypes[%q]", v.InstanceTypes)
 		}
@@ -790,6 +934,18 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 			if v.RemoteAccessUserName != "ec2-user" {
 				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", v.AMIType, v.RemoteAccessUserName)
 			}
+		case eks.AMITypesAl2Arm64:
+			if v.RemoteAccessUserName != "ec2-user" {
+				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", v.AMIType, v.RemoteAccessUserName)
+			}
+			for _, ivt := range v.InstanceTypes {
+				if !isGravitonInstanceType(ivt) {
+					return fmt.Errorf("AMIType %q requires Graviton instance types, got %q in MNGs[%q]", v.AMIType, ivt, k)
+				}
+			}
+			if cfg.IsEnabledAddOnALB2048() && cfg.AddOnALB2048.Image2048 == "" {
+				return fmt.Errorf("AMIType %q (arm64) requires a custom AddOnALB2048.Image2048 since the default 2048 image is amd64-only", v.AMIType)
+			}
 		default:
 			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, v.AMIType)
 		}
@@ -803,6 +959,10 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 			if len(v.InstanceTypes) == 0 {
 				v.InstanceTypes = []string{DefaultNodeInstanceTypeGPU}
 			}
+		case eks.AMITypesAl2Arm64:
+			if len(v.InstanceTypes) == 0 {
+				v.InstanceTypes = []string{DefaultNodeInstanceTypeArm64}
+			}
 		default:
 			return fmt.Errorf("unknown AddOnManagedNodeGroups.MNGs[%q].AMIType %q", k, v.AMIType)
 		}
@@ -876,6 +1036,40 @@ func (cfg *Config) validateAddOnALB2048() error {
 	if cfg.AddOnALB2048.Namespace == "" {
 		cfg.AddOnALB2048.Namespace = cfg.Name + "-alb-2048"
 	}
+
+	if cfg.AddOnALB2048.ControllerVersion == "" {
+		cfg.AddOnALB2048.ControllerVersion = "v1"
+	}
+	if cfg.AddOnALB2048.ControllerVersion != "v1" && cfg.AddOnALB2048.ControllerVersion != "v2" {
+		return fmt.Errorf("unknown AddOnALB2048.ControllerVersion %q", cfg.AddOnALB2048.ControllerVersion)
+	}
+	if (cfg.AddOnALB2048.ControllerImageRepo == "") != (cfg.AddOnALB2048.ControllerImageTag == "") {
+		return fmt.Errorf("AddOnALB2048.ControllerImageRepo %q and ControllerImageTag %q must be both set or both empty",
+			cfg.AddOnALB2048.ControllerImageRepo, cfg.AddOnALB2048.ControllerImageTag)
+	}
+
+	switch cfg.AddOnALB2048.RoleCreate {
+	case true: // need create one via IRSA, or already created
+		if cfg.Status.ClusterOIDCProviderARN == "" || cfg.Status.ClusterOIDCProviderURL == "" {
+			return errors.New("AddOnALB2048.RoleCreate true requires an OIDC provider associated with the cluster (Status.ClusterOIDCProviderARN/URL empty)")
+		}
+		if cfg.AddOnALB2048.RoleARN != "" {
+			// just ignore...
+			// could be populated from previous run
+			// do not error, so long as RoleCreate false, role won't be deleted
+		}
+		if len(cfg.AddOnALB2048.RoleManagedPolicyARNs) == 0 {
+			cfg.AddOnALB2048.RoleManagedPolicyARNs = []string{"arn:aws:iam::aws:policy/AWSLoadBalancerControllerIAMPolicy"}
+		}
+	case false: // use existing one
+		if cfg.AddOnALB2048.RoleARN == "" {
+			return fmt.Errorf("AddOnALB2048.RoleCreate false; expect non-empty RoleARN but got %q", cfg.AddOnALB2048.RoleARN)
+		}
+		if len(cfg.AddOnALB2048.RoleManagedPolicyARNs) > 0 {
+			return fmt.Errorf("AddOnALB2048.RoleCreate false; expect empty RoleManagedPolicyARNs but got %q", cfg.AddOnALB2048.RoleManagedPolicyARNs)
+		}
+	}
+
 	return nil
 }
 
@@ -924,6 +1118,24 @@ func (cfg *Config) validateAddOnCronJob() error {
 	return nil
 }
 
+// DefaultNodeInstanceTypeArm64 is the default EC2 instance type for
+// Graviton (arm64) managed node groups.
+const DefaultNodeInstanceTypeArm64 = "m6g.large"
+
+// gravitonInstanceTypePrefixes are the EC2 instance family prefixes that run
+// on AWS Graviton (arm64) processors.
+// ref. https://aws.amazon.com/ec2/graviton/
+var gravitonInstanceTypePrefixes = []string{"a1.", "c6g", "m6g", "r6g", "t4g."}
+
+func isGravitonInstanceType(instanceType string) bool {
+	for _, pfx := range gravitonInstanceTypePrefixes {
+		if strings.HasPrefix(instanceType, pfx) {
+			return true
+		}
+	}
+	return false
+}
+
 // only letters and numbers for Secret key names
 var secretRegex = regexp.MustCompile("[^a-zA-Z0-9]+")
 
@@ -992,6 +1204,16 @@ func (cfg *Config) validateAddOnIRSA() error {
 	return nil
 }
 
+// FargateMustPrecedeNodeGroups reports whether a deployer must finish
+// AddOnFargate's Create (profiles ACTIVE, CoreDNS rescheduled off EC2)
+// before starting node group creation. It's the config-side half of the
+// eks/scheduler.Sequence ordering: a parent deployer building its
+// scheduler.Tester map should only add AddOnFargate as a nodegroup
+// dependency when this returns true.
+func (cfg *Config) FargateMustPrecedeNodeGroups() bool {
+	return cfg.FargateFirst && cfg.IsEnabledAddOnFargate() && (cfg.IsEnabledAddOnNodeGroups() || cfg.IsEnabledAddOnManagedNodeGroups())
+}
+
 func (cfg *Config) validateAddOnFargate() error {
 	if !cfg.IsEnabledAddOnFargate() {
 		return nil
@@ -1015,7 +1237,7 @@ func (cfg *Config) validateAddOnFargate() error {
 		cfg.AddOnFargate.PodName = cfg.Name + "-fargate-pod"
 	}
 	if cfg.AddOnFargate.ContainerName == "" {
-		cfg.AddOnFargate.ContainerName = cfg.Name + "-" + randString(10)
+		cfg.AddOnFargate.ContainerName = naming.Gen(cfg.Name, 10)
 	}
 	cfg.AddOnFargate.SecretName = strings.ToLower(secretRegex.ReplaceAllString(cfg.AddOnFargate.SecretName, ""))
 
@@ -1029,6 +1251,31 @@ func (cfg *Config) validateAddOnFargate() error {
 			// could be populated from previous run
 			// do not error, so long as RoleCreate false, role won't be deleted
 		}
+		partition := GetPartition(cfg.Region)
+		if len(cfg.AddOnFargate.RoleServicePrincipals) == 0 {
+			// Default to the partition-aware principals instead of letting
+			// the CFN template's own (non-China) default apply, so a
+			// cn-north-1/cn-northwest-1 cluster gets a trust policy EKS
+			// Fargate will actually accept.
+			cfg.AddOnFargate.RoleServicePrincipals = []string{
+				RequiredServicePrincipal("eks.amazonaws.com", partition),
+				RequiredServicePrincipal("eks-fargate-pods.amazonaws.com", partition),
+			}
+		}
+		reqPrincipal := RequiredServicePrincipal("eks-fargate-pods.amazonaws.com", partition)
+		found := false
+		for _, pv := range cfg.AddOnFargate.RoleServicePrincipals {
+			if pv == reqPrincipal {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("AddOnFargate.RoleServicePrincipals %q must include %q", cfg.AddOnFargate.RoleServicePrincipals, reqPrincipal)
+		}
+		if partition == "aws-us-gov" && cfg.AddOnFargate.RoleARN != "" && !strings.Contains(cfg.AddOnFargate.RoleARN, ":aws-us-gov:") {
+			return fmt.Errorf("Region %q is GovCloud but AddOnFargate.RoleARN %q is not in the aws-us-gov partition", cfg.Region, cfg.AddOnFargate.RoleARN)
+		}
 	case false: // use existing one
 		if cfg.AddOnFargate.RoleARN == "" {
 			return fmt.Errorf("AddOnFargate.RoleCreate false; expect non-empty RoleARN but got %q", cfg.AddOnFargate.RoleARN)
@@ -1060,6 +1307,159 @@ func (cfg *Config) validateAddOnAppMesh() error {
 	return nil
 }
 
+func (cfg *Config) validateAddOnGatekeeper() error {
+	if !cfg.IsEnabledAddOnGatekeeper() {
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnGatekeeper.Enable true but no node group is enabled")
+	}
+	if cfg.Parameters.VersionValue < 1.16 {
+		return fmt.Errorf("AddOnGatekeeper requires Kubernetes 1.16+ (got %.2f)", cfg.Parameters.VersionValue)
+	}
+	if len(cfg.AddOnGatekeeper.RequiredLabels) == 0 {
+		cfg.AddOnGatekeeper.RequiredLabels = []string{"team"}
+	}
+	if cfg.AddOnGatekeeper.Namespace == "" {
+		cfg.AddOnGatekeeper.Namespace = "gatekeeper-system"
+	}
+	if cfg.AddOnGatekeeper.ResultPath == "" {
+		cfg.AddOnGatekeeper.ResultPath = filepath.Join(filepath.Dir(cfg.ConfigPath), cfg.Name+"-gatekeeper-violations.csv")
+	}
+	if filepath.Ext(cfg.AddOnGatekeeper.ResultPath) != ".csv" {
+		return fmt.Errorf("expected .csv extension for AddOnGatekeeper.ResultPath, got %q", cfg.AddOnGatekeeper.ResultPath)
+	}
+	if cfg.AddOnGatekeeper.TemplateDir != "" {
+		if err := validateGatekeeperTemplateDir(cfg.AddOnGatekeeper.TemplateDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateGatekeeperTemplateDir requires dir to contain a matching
+// "<name>-template.yaml"/"<name>-constraint.yaml" pair for every name it
+// finds, so a typo'd or half-supplied template dir fails fast at config
+// time instead of applying a ConstraintTemplate that's never enforced (or
+// a Constraint with no backing template).
+func validateGatekeeperTemplateDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read AddOnGatekeeper.TemplateDir %q (%v)", dir, err)
+	}
+	templates, constraints := map[string]bool{}, map[string]bool{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch name := e.Name(); {
+		case strings.HasSuffix(name, "-template.yaml"):
+			templates[strings.TrimSuffix(name, "-template.yaml")] = true
+		case strings.HasSuffix(name, "-constraint.yaml"):
+			constraints[strings.TrimSuffix(name, "-constraint.yaml")] = true
+		}
+	}
+	if len(templates) == 0 {
+		return fmt.Errorf("AddOnGatekeeper.TemplateDir %q has no *-template.yaml files", dir)
+	}
+	for base := range templates {
+		if !constraints[base] {
+			return fmt.Errorf("AddOnGatekeeper.TemplateDir %q: %q-template.yaml has no matching %q-constraint.yaml", dir, base, base)
+		}
+	}
+	for base := range constraints {
+		if !templates[base] {
+			return fmt.Errorf("AddOnGatekeeper.TemplateDir %q: %q-constraint.yaml has no matching %q-template.yaml", dir, base, base)
+		}
+	}
+	return nil
+}
+
+// validDrivers are the CSI drivers supported by AddOnCSI. "efs" and
+// "fsx-lustre" are deliberately left out: dynamic provisioning for either
+// needs StorageClass.Parameters (e.g. EFS's fileSystemId/provisioningMode)
+// plus a FileSystemID/FileSystemCreate config knob this tree doesn't define
+// yet, so enabling them today would deploy a StorageClass that silently
+// fails every PVC. Add them back once that wiring exists.
+var validDrivers = map[string]struct{}{
+	"ebs": {},
+}
+
+// maxTotalWriteSize caps Replicas*WriteSize, since the write/read
+// conformance suite provisions one volume per StatefulSet replica and an
+// unbounded total can run up a surprising amount of EBS spend per test run.
+const maxTotalWriteSize = 10 * 1024 * 1024 * 1024 // 10 GiB
+
+func (cfg *Config) validateAddOnCSI() error {
+	if !cfg.IsEnabledAddOnCSI() {
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCSI.Enable true but no node group is enabled")
+	}
+	if _, ok := validDrivers[cfg.AddOnCSI.Driver]; !ok {
+		return fmt.Errorf("AddOnCSI.Driver %q is not supported yet (only \"ebs\" is implemented)", cfg.AddOnCSI.Driver)
+	}
+	if cfg.AddOnCSI.Driver == "ebs" && !cfg.IsEnabledAddOnIRSA() {
+		return errors.New("AddOnCSI.Driver \"ebs\" requires AddOnIRSA.Enable true, since the EBS CSI driver's volume attach/detach calls need IAM permissions only reachable through IRSA")
+	}
+	if cfg.AddOnCSI.Namespace == "" {
+		cfg.AddOnCSI.Namespace = cfg.Name + "-csi"
+	}
+	if cfg.AddOnCSI.StorageClassName == "" {
+		switch cfg.AddOnCSI.Driver {
+		case "ebs":
+			cfg.AddOnCSI.StorageClassName = "gp3"
+		}
+	}
+	if cfg.AddOnCSI.StatefulSetName == "" {
+		cfg.AddOnCSI.StatefulSetName = cfg.Name + "-csi-" + cfg.AddOnCSI.Driver
+	}
+	if cfg.AddOnCSI.Replicas == 0 {
+		cfg.AddOnCSI.Replicas = 3
+	}
+	if cfg.AddOnCSI.WriteSize == 0 {
+		cfg.AddOnCSI.WriteSize = 10 * 1024 * 1024 // 10 MB
+	}
+	if int64(cfg.AddOnCSI.Replicas)*int64(cfg.AddOnCSI.WriteSize) > maxTotalWriteSize {
+		return fmt.Errorf("AddOnCSI.Replicas (%d) * WriteSize (%d) exceeds the %d-byte (10 GiB) cap", cfg.AddOnCSI.Replicas, cfg.AddOnCSI.WriteSize, int64(maxTotalWriteSize))
+	}
+	if cfg.AddOnCSI.ResultPath == "" {
+		cfg.AddOnCSI.ResultPath = filepath.Join(filepath.Dir(cfg.ConfigPath), cfg.Name+"-csi-"+cfg.AddOnCSI.Driver+"-latencies.csv")
+	}
+	if filepath.Ext(cfg.AddOnCSI.ResultPath) != ".csv" {
+		return fmt.Errorf("expected .csv extension for ResultPath, got %q", cfg.AddOnCSI.ResultPath)
+	}
+	return nil
+}
+
+// GetPartition returns the AWS partition for the given region, so
+// "ec2.amazonaws.com"-style service principals can be translated to their
+// China-region equivalent (e.g. "ec2.amazonaws.com.cn"). Exported so
+// packages outside eksconfig (e.g. eks/fargate) that build their own
+// trust-policy principals can stay consistent with Config's own defaulting
+// and validation instead of hardcoding the global-partition principal.
+func GetPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+// RequiredServicePrincipal returns the partition-specific form of a global
+// service principal (only "aws-cn" rewrites the suffix; "aws-us-gov" and
+// "aws" both use the global "amazonaws.com" principal).
+func RequiredServicePrincipal(principal, partition string) string {
+	if partition == "aws-cn" {
+		return principal + ".cn"
+	}
+	return principal
+}
+
 // get "role-eks" from "arn:aws:iam::123:role/role-eks"
 func getNameFromARN(arn string) string {
 	if ss := strings.Split(arn, "/"); len(ss) > 0 {
@@ -0,0 +1,76 @@
+package eksconfig
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ValidationError describes one field that failed to parse or validate,
+// carrying enough context to point a user at the exact setting that's
+// wrong instead of a bare fmt.Errorf string.
+type ValidationError struct {
+	// AddOn is the add-on/section the field belongs to (e.g.
+	// "AddOnFargate"), or "Config"/"Parameters" for top-level sections.
+	AddOn string
+	// EnvKey is the environment variable key involved, if the error came
+	// from UpdateFromEnvs.
+	EnvKey string
+	// JSONPath is the field's dotted JSON-tag path (e.g.
+	// "irsa.service-account-name").
+	JSONPath string
+	// RawValue is the raw string value that failed to parse, if any.
+	RawValue string
+	// Cause is the underlying error.
+	Cause error
+}
+
+func (e ValidationError) Error() string {
+	var sb strings.Builder
+	if e.AddOn != "" {
+		fmt.Fprintf(&sb, "[%s]", e.AddOn)
+	}
+	if e.JSONPath != "" {
+		fmt.Fprintf(&sb, " %s", e.JSONPath)
+	}
+	if e.EnvKey != "" {
+		fmt.Fprintf(&sb, " (env %s)", e.EnvKey)
+	}
+	if e.RawValue != "" {
+		fmt.Fprintf(&sb, " = %q", e.RawValue)
+	}
+	if e.Cause != nil {
+		fmt.Fprintf(&sb, ": %v", e.Cause)
+	}
+	return strings.TrimPrefix(sb.String(), " ")
+}
+
+// Unwrap lets errors.Is/errors.As reach Cause.
+func (e ValidationError) Unwrap() error { return e.Cause }
+
+// ValidationErrors aggregates every ValidationError found across a single
+// UpdateFromEnvs or ValidateAndSetDefaults pass, so a caller seeing a
+// misconfigured cluster learns about every bad section at once instead of
+// only the first one found. UpdateFromEnvs records every env var that fails
+// to parse; ValidateAndSetDefaults records at most one error per section,
+// since each section's validator stops at its first failure.
+type ValidationErrors []ValidationError
+
+func (es ValidationErrors) Error() string {
+	if len(es) == 0 {
+		return "no validation errors"
+	}
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Format writes one line per error to w, for a more readable report than
+// Error()'s single semicolon-joined line.
+func (es ValidationErrors) Format(w io.Writer) {
+	for _, e := range es {
+		fmt.Fprintln(w, e.Error())
+	}
+}
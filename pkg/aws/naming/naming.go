@@ -0,0 +1,50 @@
+// Package naming generates short, collision-resistant resource names that
+// stay within the AWS/EKS 63-character name limit.
+package naming
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// MaxLength is the longest name EKS accepts for a cluster, Fargate profile,
+// node group, or IAM role.
+const MaxLength = 63
+
+const charset = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// Gen returns tag suffixed with an n-character crypto/rand base36 string
+// (e.g. Gen("my-cluster-fargate-profile", 8)), truncating tag as needed so
+// the result never exceeds MaxLength. The suffix is never truncated, so two
+// calls with the same tag and n are still collision-resistant even once
+// tag itself no longer fits.
+func Gen(tag string, n int) string {
+	suffix := Rand(n)
+	if max := MaxLength - 1 - len(suffix); len(tag) > max {
+		tag = tag[:max]
+	}
+	return tag + "-" + suffix
+}
+
+// Rand returns a bare n-character base36 string read from crypto/rand, for
+// callers that need a random suffix without Gen's "tag-suffix" shape (e.g.
+// a temporary file name).
+func Rand(n int) string {
+	return randSuffix(n)
+}
+
+// randSuffix returns an n-character base36 string read from crypto/rand, so
+// names generated back-to-back on the same machine don't collide the way
+// math/rand seeded from time.Now().UnixNano() can.
+func randSuffix(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			panic(fmt.Sprintf("naming: failed to read random bytes: %v", err))
+		}
+		b[i] = charset[idx.Int64()]
+	}
+	return string(b)
+}
@@ -0,0 +1,25 @@
+package naming
+
+import "testing"
+
+// TestGenNoDuplicates asserts that 1,000,000 names generated back-to-back
+// for the same tag never collide, since Gen callers (e.g. Fargate profile
+// and node group names) rely on that to avoid clobbering an existing AWS
+// resource. It uses a 12-character suffix (36^12 values, so a collision
+// among 1,000,000 names has a chance of about 1 in 10 million); with 8
+// characters the chance would be about 1 in 6.
+func TestGenNoDuplicates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 1,000,000-name generation in short mode")
+	}
+
+	const total = 1000000
+	seen := make(map[string]struct{}, total)
+	for i := 0; i < total; i++ {
+		name := Gen("my-cluster-fargate-profile", 12)
+		if _, ok := seen[name]; ok {
+			t.Fatalf("duplicate name generated after %d calls: %q", i, name)
+		}
+		seen[name] = struct{}{}
+	}
+}
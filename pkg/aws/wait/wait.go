@@ -0,0 +1,65 @@
+// Package wait provides small, reusable polling helpers so callers don't
+// need to hand-roll a ctx/stopc-aware backoff loop every time they wait for
+// an AWS resource to reach some state.
+// ref. k8s.io/apimachinery/pkg/util/wait
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTerminal marks a ConditionFunc error as non-retryable. Wrap it with
+// fmt.Errorf("%w: ...", wait.ErrTerminal, ...) to stop PollImmediateUntil
+// immediately instead of waiting out the full timeout.
+var ErrTerminal = errors.New("terminal error")
+
+// ConditionFunc reports whether the awaited condition has been met. A
+// non-nil error not wrapping ErrTerminal is treated as transient and the
+// condition is retried after the next interval; an error wrapping
+// ErrTerminal stops polling immediately.
+type ConditionFunc func() (done bool, err error)
+
+// PollImmediateUntil calls fn immediately, then waits initial before the
+// second call and interval before every call after that, until fn reports
+// done, fn returns a terminal error, timeout elapses, or ctx/stopc is
+// signaled. The immediate first call matters when the awaited state may
+// already hold by the time polling starts.
+func PollImmediateUntil(ctx context.Context, stopc <-chan struct{}, initial, interval, timeout time.Duration, fn ConditionFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wait := time.Duration(0)
+	afterFirst := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stopc:
+			return errors.New("wait stopped")
+		case <-time.After(wait):
+		}
+
+		done, err := fn()
+		if err != nil && errors.Is(err, ErrTerminal) {
+			return err
+		}
+		if err == nil && done {
+			return nil
+		}
+
+		if !afterFirst {
+			wait = initial
+			afterFirst = true
+		} else {
+			wait = interval
+		}
+	}
+}
+
+// Terminal wraps err so PollImmediateUntil treats it as non-retryable.
+func Terminal(err error) error {
+	return fmt.Errorf("%w: %v", ErrTerminal, err)
+}
@@ -0,0 +1,125 @@
+// Package retry wraps client-go Create/Delete/Get calls with an exponential
+// backoff that tolerates transient failures (API server throttling, a
+// not-yet-ready admission webhook, a 5xx), rather than failing a tester on
+// the first blip.
+// ref. https://github.com/openshift/ARO-RP e2e retry-wrapper pattern
+package retry
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	errAborted  = errors.New("check aborted")
+	errNotReady = errors.New("Deployment not ready")
+)
+
+// Config controls the backoff used by the retry helpers in this package.
+type Config struct {
+	Duration time.Duration
+	Factor   float64
+	Steps    int
+}
+
+// DefaultConfig makes up to 7 attempts, waiting 1s, 2s, 4s, 8s, 16s and 32s
+// between them (about 1 minute in all), enough to ride out most transient
+// API server errors.
+var DefaultConfig = Config{Duration: time.Second, Factor: 2.0, Steps: 7}
+
+func (c Config) backoff() wait.Backoff {
+	return wait.Backoff{Duration: c.Duration, Factor: c.Factor, Steps: c.Steps}
+}
+
+// CreateWithRetry retries create until it succeeds or the backoff is
+// exhausted, then returns the last error. An IsAlreadyExists error is
+// treated as success, since the desired end state (the object exists)
+// already holds; every other error is retried.
+func CreateWithRetry(lg *zap.Logger, label string, cfg Config, create func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(cfg.backoff(), func() (bool, error) {
+		lastErr = create()
+		if lastErr == nil {
+			return true, nil
+		}
+		if apierrors.IsAlreadyExists(lastErr) {
+			lg.Info("already exists; treating create as success", zap.String("op", label))
+			return true, nil
+		}
+		lg.Warn("create failed; retrying", zap.String("op", label), zap.Error(lastErr))
+		return false, nil
+	})
+	if err != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// DeleteWithRetry retries delete until it succeeds or the backoff is
+// exhausted, then returns the last error. An IsNotFound error is treated as
+// success; every other error is retried.
+func DeleteWithRetry(lg *zap.Logger, label string, cfg Config, del func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(cfg.backoff(), func() (bool, error) {
+		lastErr = del()
+		if lastErr == nil {
+			return true, nil
+		}
+		if apierrors.IsNotFound(lastErr) {
+			lg.Info("already deleted; treating delete as success", zap.String("op", label))
+			return true, nil
+		}
+		lg.Warn("delete failed; retrying", zap.String("op", label), zap.Error(lastErr))
+		return false, nil
+	})
+	if err != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// GetWithRetry retries get until it succeeds or the backoff is exhausted.
+func GetWithRetry(lg *zap.Logger, label string, cfg Config, get func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(cfg.backoff(), func() (bool, error) {
+		lastErr = get()
+		if lastErr == nil {
+			return true, nil
+		}
+		lg.Warn("get failed; retrying", zap.String("op", label), zap.Error(lastErr))
+		return false, nil
+	})
+	if err != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// WaitDeploymentAvailable polls check every interval until it reports the
+// Deployment ready, waitDur elapses, or stopc/sig fires.
+func WaitDeploymentAvailable(stopc <-chan struct{}, sig <-chan os.Signal, waitDur, interval time.Duration, check func() (ready bool, err error)) error {
+	retryStart := time.Now()
+	for time.Now().Sub(retryStart) < waitDur {
+		select {
+		case <-stopc:
+			return errAborted
+		case <-sig:
+			return errAborted
+		case <-time.After(interval):
+		}
+
+		ready, err := check()
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+	}
+	return errNotReady
+}